@@ -0,0 +1,161 @@
+package sanitizer
+
+import (
+	"html"
+	"strings"
+)
+
+// dangerousAttributes are removed whatever their value, srcdoc holds a whole document and formaction
+// sends the form to another URL
+var dangerousAttributes = map[string]bool{
+	"srcdoc":     true,
+	"formaction": true,
+}
+
+// linkAttributes hold URLs that are loaded or followed, besides the ones checked by policies
+var linkAttributes = map[string]bool{
+	"data":     true,
+	"codebase": true,
+	"dynsrc":   true,
+	"lowsrc":   true,
+}
+
+// scriptSchemes run code when a link is followed or loaded
+var scriptSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"livescript": true,
+}
+
+// Attributes removes event handlers like onerror, style values running code, srcdoc, formaction and links to
+// javascript:, vbscript: or data: URLs. Tags without such attributes and the rest of the markup are kept as written.
+func Attributes(input string) string {
+	if !strings.Contains(input, "<") {
+		return input
+	}
+
+	var b strings.Builder
+	b.Grow(len(input))
+
+	z := newTokenizer(input, false)
+	for {
+		offset := z.pos
+		t, ok := z.next()
+		if !ok {
+			break
+		}
+
+		if t.typ == startTagToken || t.typ == selfClosingTagToken {
+			if kept, removed := safeAttributes(t.attrs); removed {
+				// Unterminated tags are dropped by browsers, closing them would bring them back
+				if !t.eof {
+					writeAttributes(&b, t, kept)
+				}
+				continue
+			}
+		}
+
+		b.WriteString(input[offset:z.pos])
+	}
+
+	return b.String()
+}
+
+// safeAttributes returns the attributes that are not dangerous and whether any was removed
+func safeAttributes(attrs []attribute) ([]attribute, bool) {
+	var kept []attribute
+	for _, a := range attrs {
+		if !dangerousAttribute(a) {
+			kept = append(kept, a)
+		}
+	}
+
+	return kept, len(kept) != len(attrs)
+}
+
+// dangerousAttribute reports whether an attribute can run code or load a document
+func dangerousAttribute(a attribute) bool {
+	switch {
+	// Event handlers, open is the only other attribute starting with on
+	case strings.HasPrefix(a.name, "on") && a.name != "open":
+		return true
+
+	case dangerousAttributes[a.name]:
+		return true
+
+	case a.name == "style":
+		return dangerousStyle(a.value)
+
+	case urlAttributes[a.name] || linkAttributes[a.name]:
+		return dangerousURL(a.value)
+	}
+
+	return false
+}
+
+// dangerousStyle reports whether a style value runs code, CSS escapes and comments are removed before matching
+func dangerousStyle(value string) bool {
+	css := cssCommentRegex.ReplaceAllString(html.UnescapeString(value), "")
+	css = cssEscapeRegex.ReplaceAllStringFunc(css, decodeCSSEscape)
+	css = strings.ToLower(canonicalize(css).text)
+
+	return cssThreatRegex.MatchString(strings.Join(strings.Fields(css), ""))
+}
+
+// decodeCSSEscape decodes an escape like \65 or \( matched by cssEscapeRegex
+func decodeCSSEscape(escape string) string {
+	escape = strings.TrimRight(escape[1:], " \t\n\f\r")
+
+	var r rune
+	for _, c := range escape {
+		switch {
+		case c >= '0' && c <= '9':
+			r = r*16 + c - '0'
+		case c >= 'a' && c <= 'f':
+			r = r*16 + c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			r = r*16 + c - 'A' + 10
+		default:
+			// Not hex, the character stands for itself
+			return escape
+		}
+	}
+
+	return string(r)
+}
+
+// dangerousURL reports whether a URL uses a script scheme or is a data: URL other than an image,
+// entities, percent encoding and control characters are decoded first like browsers do
+func dangerousURL(value string) bool {
+	u := strings.ToLower(strings.TrimLeft(canonicalize(value).text, " "))
+
+	scheme, rest, ok := strings.Cut(u, ":")
+	if !ok {
+		return false
+	}
+
+	if scriptSchemes[scheme] {
+		return true
+	}
+
+	return scheme == "data" && (!strings.HasPrefix(rest, "image/") || strings.HasPrefix(rest, "image/svg"))
+}
+
+// writeAttributes writes a start tag with the given attributes, values are escaped and double quoted
+func writeAttributes(b *strings.Builder, t token, attrs []attribute) {
+	b.WriteString("<" + t.name)
+
+	for _, a := range attrs {
+		b.WriteString(" " + a.name)
+		if a.value != "" {
+			b.WriteString(`="` + html.EscapeString(html.UnescapeString(a.value)) + `"`)
+		}
+	}
+
+	if t.typ == selfClosingTagToken {
+		b.WriteString("/>")
+		return
+	}
+
+	b.WriteString(">")
+}
@@ -0,0 +1,59 @@
+package sanitizer
+
+import "testing"
+
+func TestAttributes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "No markup", input: "onload=alert(1)", want: "onload=alert(1)"},
+		{name: "Safe tags are kept as written", input: `<P Class='x'>Hi <a href="/a?b=1&amp;c=2">x</a></P>`, want: `<P Class='x'>Hi <a href="/a?b=1&amp;c=2">x</a></P>`},
+		{name: "Event handlers", input: `<img src="x.png" onerror="alert(1)" ONLOAD=go()>`, want: `<img src="x.png">`},
+		{name: "Handler after a slash", input: `<svg/onload=alert(1)>`, want: `<svg>`},
+		{name: "Open is kept", input: `<details open onToggle="x()">`, want: `<details open>`},
+		{name: "Self closing tag", input: `<br onclick="x()"/>`, want: `<br/>`},
+		{name: "Style expression", input: `<div style="width: expression(alert(1))">x</div>`, want: `<div>x</div>`},
+		{name: "Style with escapes and comments", input: `<div style="width: ex/**/pr\65 ssion(alert(1))">x</div>`, want: `<div>x</div>`},
+		{name: "Style url javascript", input: `<div style="background: url('javascript:alert(1)')">x</div>`, want: `<div>x</div>`},
+		{name: "Style behavior", input: `<div style="behavior: url(x.htc)">x</div>`, want: `<div>x</div>`},
+		{name: "Safe style is kept", input: `<div style="color: red" onclick="x()">x</div>`, want: `<div style="color: red">x</div>`},
+		{name: "Javascript link", input: `<a href="javascript:alert(1)" title="t">x</a>`, want: `<a title="t">x</a>`},
+		{name: "Obfuscated javascript link", input: `<a href=" jav&#x61;&#x09;script:alert(1)">x</a>`, want: `<a>x</a>`},
+		{name: "Vbscript link", input: `<a href="VBScript:x">x</a>`, want: `<a>x</a>`},
+		{name: "Data document", input: `<iframe src="data:text/html,<script>x</script>"></iframe>`, want: `<iframe></iframe>`},
+		{name: "Data svg", input: `<object data="data:image/svg+xml;base64,PHN2Zz4="></object>`, want: `<object></object>`},
+		{name: "Data image is kept", input: `<img src="data:image/png;base64,iVBORw0KGgo=">`, want: `<img src="data:image/png;base64,iVBORw0KGgo=">`},
+		{name: "Formaction", input: `<button formaction="https://evil.example">x</button>`, want: `<button>x</button>`},
+		{name: "Srcdoc", input: `<iframe srcdoc="<script>alert(1)</script>"></iframe>`, want: `<iframe></iframe>`},
+		{name: "Values are escaped when a tag is rewritten", input: `<a title='say "hi"' onclick=x>x</a>`, want: `<a title="say &#34;hi&#34;">x</a>`},
+		{name: "Unterminated tag with a handler is dropped", input: `a<img src=x onerror="alert(1)`, want: `a`},
+		{name: "Script content is kept", input: `<script>x.onload = f</script>`, want: `<script>x.onload = f</script>`},
+		{name: "Style inside svg is markup", input: `<svg><style><img src=x onerror=alert(1)></style></svg>`, want: `<svg><style><img src="x"></style></svg>`},
+		{name: "Title inside math is markup", input: `<math><mtext><title><img src=x onerror=alert(1)></title></mtext></math>`, want: `<math><mtext><title><img src="x"></title></mtext></math>`},
+		{name: "Style after svg is raw text", input: `<svg></svg><style>a[onclick=x] {}</style>`, want: `<svg></svg><style>a[onclick=x] {}</style>`},
+		{name: "Comments and CDATA are kept", input: `<!-- <a onclick=x> --><![CDATA[x]]>`, want: `<!-- <a onclick=x> --><![CDATA[x]]>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Attributes(tt.input); got != tt.want {
+				t.Errorf("Attributes() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructAttributes(t *testing.T) {
+	payload := &struct {
+		Body string `sanitize:"attrs"`
+	}{Body: `<p onclick="steal()">Hello</p>`}
+
+	if err := New().SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if payload.Body != "<p>Hello</p>" {
+		t.Errorf("SanitizeStruct() Body = %s, want <p>Hello</p>", payload.Body)
+	}
+}
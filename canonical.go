@@ -0,0 +1,96 @@
+package sanitizer
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// maxDecodeRounds bounds how many nested encodings are decoded, e.g. &#x25;6A is decoded to %6A and then to j
+const maxDecodeRounds = 3
+
+// canonical is the input with its entities, percent encoding and JS escapes decoded and its control characters removed,
+// origins holds for every byte of text the byte range of the input it was decoded from
+type canonical struct {
+	text    string
+	origins [][2]int
+}
+
+// canonicalize decodes the obfuscation of input so threats can be matched on the decoded text
+func canonicalize(input string) canonical {
+	c := decodeOnce(input)
+
+	for round := 1; round < maxDecodeRounds; round++ {
+		next := decodeOnce(c.text)
+		if next.text == c.text {
+			break
+		}
+
+		// Map the ranges of the decoded text back to the input
+		for i, origin := range next.origins {
+			next.origins[i] = [2]int{c.origins[origin[0]][0], c.origins[origin[1]-1][1]}
+		}
+		c = next
+	}
+
+	return c
+}
+
+// span returns the range of the input the decoded bytes text[start:end] come from
+func (c canonical) span(start, end int) [2]int {
+	return [2]int{c.origins[start][0], c.origins[end-1][1]}
+}
+
+// decodeOnce decodes a single layer of encoding
+func decodeOnce(input string) canonical {
+	var b strings.Builder
+	b.Grow(len(input))
+
+	origins := make([][2]int, 0, len(input))
+	for i := 0; i < len(input); {
+		decoded, n := decodeAt(input, i)
+		for j := 0; j < len(decoded); j++ {
+			origins = append(origins, [2]int{i, i + n})
+		}
+
+		b.WriteString(decoded)
+		i += n
+	}
+
+	return canonical{text: b.String(), origins: origins}
+}
+
+// decodeAt decodes the escape starting at i and returns it with the number of bytes it used,
+// control characters are dropped since browsers ignore tabs and new lines inside URLs like java\tscript:
+func decodeAt(s string, i int) (string, int) {
+	switch c := s[i]; {
+	case c < ' ' || c == 0x7f:
+		return "", 1
+
+	case c == '&':
+		// Numeric and named entities, with or without their ;
+		if m := entityRegex.FindString(s[i:]); m != "" {
+			if decoded := html.UnescapeString(m); decoded != m {
+				return decoded, len(m)
+			}
+		}
+
+	case c == '%':
+		if i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				return string([]byte{byte(v)}), 3
+			}
+		}
+
+	case c == '\\':
+		// \x6A, \u006A and \u{6A}
+		if m := jsEscapeRegex.FindStringSubmatch(s[i:]); m != nil {
+			digits := m[1] + m[2] + m[3]
+			if v, err := strconv.ParseUint(digits, 16, 32); err == nil && v <= 0x10ffff {
+				return string(rune(v)), len(m[0])
+			}
+		}
+	}
+
+	return s[i : i+1], 1
+}
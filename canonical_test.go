@@ -0,0 +1,32 @@
+package sanitizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		text    string
+		origins [][2]int
+	}{
+		{name: "Plain text", input: "ab", text: "ab", origins: [][2]int{{0, 1}, {1, 2}}},
+		{name: "Entity", input: "a&lt;", text: "a<", origins: [][2]int{{0, 1}, {1, 5}}},
+		{name: "Unknown entity is kept", input: "&zz;", text: "&zz;", origins: [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}}},
+		{name: "Percent encoding", input: "%41%zz", text: "A%zz", origins: [][2]int{{0, 3}, {3, 4}, {4, 5}, {5, 6}}},
+		{name: "JS escapes", input: `\x41\u{42}\q`, text: `AB\q`, origins: [][2]int{{0, 4}, {4, 10}, {10, 11}, {11, 12}}},
+		{name: "Control characters are dropped", input: "a\t\x00b", text: "ab", origins: [][2]int{{0, 1}, {3, 4}}},
+		{name: "Nested encodings", input: "&#x25;41", text: "A", origins: [][2]int{{0, 8}}},
+		{name: "Multi byte entity", input: "&eacute;", text: "é", origins: [][2]int{{0, 8}, {0, 8}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := canonicalize(tt.input)
+			if got.text != tt.text || !reflect.DeepEqual(got.origins, tt.origins) {
+				t.Errorf("canonicalize() = %q %v, want %q %v", got.text, got.origins, tt.text, tt.origins)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package sanitizer
+
+import "reflect"
+
+// Clean returns a sanitized deep copy of v using the "sanitize" tag, v can be a struct,
+// a pointer to a struct or any value holding structs, the original is left untouched
+func Clean[T any](v T) (T, error) {
+	return CleanWith(defaultSanitizer(defaultTagName), v)
+}
+
+// CleanWith returns a sanitized deep copy of v using the given sanitizer, failed fields keep their value in the copy
+func CleanWith[T any](st *StructSanitizer, v T) (T, error) {
+	value := deepCopy(reflect.ValueOf(&v).Elem())
+
+	w := st.newWalker()
+	w.copied = true
+	w.readClone(value)
+
+	return *value.Addr().Interface().(*T), w.err()
+}
+
+// readClone sanitizes a copy made by deepCopy, pointers to structs are read like SanitizeStruct would
+func (w *walker) readClone(value reflect.Value) {
+	root := value
+	for root.Kind() == reflect.Ptr && !root.IsNil() {
+		w.visit(root)
+		root = root.Elem()
+	}
+
+	if root.Kind() == reflect.Struct {
+		w.readRoot(root)
+		return
+	}
+
+	w.readValue(value, "", fieldRules{})
+}
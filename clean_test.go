@@ -0,0 +1,175 @@
+package sanitizer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type sharedPayload struct {
+	*embeddedAddress
+	Name string `sanitize:"html"`
+}
+
+func newCleanPayload() Payload {
+	active := true
+
+	return Payload{
+		FirstName: `<script>alert("first")</script>First`,
+		Website:   "https://www.domain.com",
+		Address:   Address{StreetAddress1: `<b>Street</b>`},
+		Active:    &active,
+		Tags:      []string{`<script>alert("tag")</script>tag`},
+		Comments:  []string{`<b>comment</b>`},
+	}
+}
+
+func TestClean(t *testing.T) {
+	active := true
+	want := Payload{
+		FirstName: "First",
+		Website:   "https://domain.com",
+		Address:   Address{StreetAddress1: "Street"},
+		Active:    &active,
+		Tags:      []string{"tag"},
+		Comments:  []string{"comment"},
+	}
+
+	t.Run("Value", func(t *testing.T) {
+		original := newCleanPayload()
+
+		got, err := Clean(original)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Clean() = %+v, want %+v", got, want)
+		}
+		if !reflect.DeepEqual(original, newCleanPayload()) {
+			t.Errorf("Clean() changed the original to %+v", original)
+		}
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		payload := newCleanPayload()
+		original := &payload
+
+		got, err := Clean(original)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		if got == original || got.Active == original.Active {
+			t.Errorf("Clean() returned memory shared with the original")
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("Clean() = %+v, want %+v", *got, want)
+		}
+		if !reflect.DeepEqual(payload, newCleanPayload()) {
+			t.Errorf("Clean() changed the original to %+v", payload)
+		}
+	})
+
+	t.Run("Slice of structs", func(t *testing.T) {
+		original := []Payload{newCleanPayload()}
+
+		got, err := Clean(original)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, []Payload{want}) {
+			t.Errorf("Clean() = %+v, want %+v", got, []Payload{want})
+		}
+		if !reflect.DeepEqual(original, []Payload{newCleanPayload()}) {
+			t.Errorf("Clean() changed the original to %+v", original)
+		}
+	})
+
+	t.Run("Interface", func(t *testing.T) {
+		payload := newCleanPayload()
+
+		got, err := Clean[any](&payload)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		if !reflect.DeepEqual(*got.(*Payload), want) {
+			t.Errorf("Clean() = %+v, want %+v", got, want)
+		}
+		if !reflect.DeepEqual(payload, newCleanPayload()) {
+			t.Errorf("Clean() changed the original to %+v", payload)
+		}
+	})
+
+	t.Run("Nil pointer", func(t *testing.T) {
+		got, err := Clean((*Payload)(nil))
+		if got != nil || err != nil {
+			t.Errorf("Clean() = %v, %v, want nil, nil", got, err)
+		}
+	})
+
+	t.Run("Failed fields", func(t *testing.T) {
+		original := Payload{Website: "http://[::1]:namedport", Tags: []string{"<b>tag</b>"}}
+
+		got, err := Clean(original)
+
+		var errs *Errors
+		if !errors.As(err, &errs) || len(errs.Fields) != 1 || errs.Fields[0].Path != "Website" {
+			t.Fatalf("Clean() error = %v, want a Website failure", err)
+		}
+		if got.Website != original.Website || !reflect.DeepEqual(got.Tags, []string{"tag"}) {
+			t.Errorf("Clean() = %+v", got)
+		}
+		if original.Tags[0] != "<b>tag</b>" {
+			t.Errorf("Clean() changed the original to %+v", original)
+		}
+	})
+
+	t.Run("Pointers behind unexported fields", func(t *testing.T) {
+		original := sharedPayload{embeddedAddress: &embeddedAddress{Street: "<b>street</b>"}, Name: "<b>name</b>"}
+
+		got, err := Clean(original)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		if got.Name != "name" || got.Street != "<b>street</b>" || original.Street != "<b>street</b>" {
+			t.Errorf("Clean() = %+v, original %+v", got, original)
+		}
+	})
+}
+
+func TestCleanWith(t *testing.T) {
+	st := New(WithStrict(true))
+
+	got, err := CleanWith(st, struct {
+		Name string `sanitize:"unknown"`
+	}{Name: "name"})
+	if !errors.Is(err, ErrUnknownRule) || got.Name != "name" {
+		t.Errorf("CleanWith() = %+v, %v, want ErrUnknownRule", got, err)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	first := &node{Name: "first"}
+	second := &node{Name: "second", Next: first}
+	first.Next = second
+
+	shared := map[string]any{"list": []any{"a", first}}
+	original := map[string]any{"a": shared, "b": shared}
+
+	copied := deepCopy(reflect.ValueOf(original)).Interface().(map[string]any)
+	if !reflect.DeepEqual(copied, original) {
+		t.Fatalf("deepCopy() = %v, want %v", copied, original)
+	}
+
+	a := copied["a"].(map[string]any)
+	if reflect.ValueOf(a).Pointer() == reflect.ValueOf(shared).Pointer() {
+		t.Errorf("deepCopy() shares a map with the original")
+	}
+	if reflect.ValueOf(a).Pointer() != reflect.ValueOf(copied["b"]).Pointer() {
+		t.Errorf("deepCopy() copied a shared map twice")
+	}
+
+	copiedFirst := a["list"].([]any)[1].(*node)
+	if copiedFirst == first || copiedFirst.Next.Next != copiedFirst {
+		t.Errorf("deepCopy() did not keep the cycle inside the copy")
+	}
+}
@@ -0,0 +1,718 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JoshuaJimenezR/sanitizer"
+)
+
+// generatedHeader marks the files written by sanitizegen, they are skipped when parsing
+const generatedHeader = "// Code generated by sanitizegen. DO NOT EDIT."
+
+// typeKind is the shape of a field type as far as sanitization is concerned
+type typeKind int
+
+const (
+	kindOther     typeKind = iota // numbers, booleans, functions, channels
+	kindString                    // string and named string types
+	kindStruct                    // named or anonymous structs
+	kindPointer                   // pointers
+	kindSlice                     // slices
+	kindArray                     // arrays
+	kindMap                       // maps
+	kindInterface                 // interfaces and any, only StructSanitizer can walk them
+	kindExternal                  // types from other packages
+)
+
+// fieldType is a resolved field type
+type fieldType struct {
+	kind   typeKind
+	name   string          // name of a named type in the package, empty otherwise
+	fields *ast.StructType // fields of anonymous structs
+	elem   *fieldType      // pointer, slice, array and map values
+	key    *fieldType      // map keys
+}
+
+// generator writes Sanitize methods for the tagged structs of a package
+type generator struct {
+	tagName string
+	output  string
+
+	fset    *token.FileSet
+	pkg     string
+	decls   map[string]*ast.TypeSpec
+	order   []string
+	methods map[string]bool            // types with a Sanitize method, generated or hand written
+	custom  map[string]bool            // types with a hand written Sanitize method
+	hooks   map[string]map[string]bool // hand written BeforeSanitize, AfterSanitize and SanitizeWith methods by type
+	imports map[string]bool
+	depth   int
+}
+
+// generate parses the package in dir and returns the formatted source of the Sanitize methods
+func (g *generator) generate(dir string) ([]byte, error) {
+	if err := g.parse(dir); err != nil {
+		return nil, err
+	}
+
+	g.findMethods()
+	g.imports = map[string]bool{}
+
+	var body bytes.Buffer
+	for _, name := range g.order {
+		// SanitizeWith replaces the walk like Sanitize, it is left to StructSanitizer
+		if !g.methods[name] || g.custom[name] || g.hooks[name]["SanitizeWith"] {
+			continue
+		}
+
+		code, err := g.method(name, g.decls[name].Type.(*ast.StructType))
+		if err != nil {
+			return nil, err
+		}
+
+		body.WriteString(code)
+	}
+
+	if body.Len() == 0 {
+		return nil, fmt.Errorf("no struct with %s tags found in %s", g.tagName, dir)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "%s\n\npackage %s\n\nimport (\n", generatedHeader, g.pkg)
+
+	imports := []string{`"github.com/JoshuaJimenezR/sanitizer"`}
+	for imp := range g.imports {
+		imports = append(imports, strconv.Quote(imp))
+	}
+	sort.Strings(imports)
+
+	for _, imp := range imports {
+		fmt.Fprintf(&src, "\t%s\n", imp)
+	}
+	src.WriteString(")\n")
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v\n%s", err, src.Bytes())
+	}
+
+	return formatted, nil
+}
+
+// parse reads the type declarations of every non-test Go file in dir
+func (g *generator) parse(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	g.fset = token.NewFileSet()
+	g.decls = map[string]*ast.TypeSpec{}
+	g.custom = map[string]bool{}
+	g.hooks = map[string]map[string]bool{}
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == g.output {
+			continue
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if bytes.HasPrefix(src, []byte(generatedHeader)) {
+			continue
+		}
+
+		file, err := parser.ParseFile(g.fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+
+		if g.pkg != "" && g.pkg != file.Name.Name {
+			return fmt.Errorf("%s: package %s, expected %s", path, file.Name.Name, g.pkg)
+		}
+		g.pkg = file.Name.Name
+
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				g.addMethod(fn)
+				continue
+			}
+
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				g.decls[ts.Name.Name] = ts
+				g.order = append(g.order, ts.Name.Name)
+			}
+		}
+	}
+
+	if g.pkg == "" {
+		return fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	return nil
+}
+
+// findMethods marks the structs with tagged fields or hooks, the types with a hand written Sanitize method and the structs holding them
+func (g *generator) findMethods() {
+	g.methods = map[string]bool{}
+	for name := range g.custom {
+		g.methods[name] = true
+	}
+
+	for name, ts := range g.decls {
+		st, ok := ts.Type.(*ast.StructType)
+		if ok && ts.TypeParams == nil && (g.hasTags(st) || len(g.hooks[name]) > 0) {
+			g.methods[name] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for name, ts := range g.decls {
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.TypeParams != nil || g.methods[name] {
+				continue
+			}
+
+			for _, field := range st.Fields.List {
+				if g.needsWork(g.resolve(field.Type, 0)) {
+					g.methods[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// hasTags reports whether a struct, or an anonymous struct inside it, has tagged fields
+func (g *generator) hasTags(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if g.tag(field) != "" {
+			return true
+		}
+
+		if inner, ok := field.Type.(*ast.StructType); ok && g.hasTags(inner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// needsWork reports whether values of t hold a type with a Sanitize method or hooks
+func (g *generator) needsWork(t *fieldType) bool {
+	if g.custom[t.name] || len(g.hooks[t.name]) > 0 {
+		return true
+	}
+
+	switch t.kind {
+	case kindStruct:
+		if t.fields != nil {
+			for _, field := range t.fields.Fields.List {
+				if g.needsWork(g.resolve(field.Type, 0)) {
+					return true
+				}
+			}
+			return g.hasTags(t.fields)
+		}
+
+		return g.methods[t.name]
+
+	case kindPointer, kindSlice, kindArray, kindMap:
+		return g.needsWork(t.elem)
+	}
+
+	return false
+}
+
+// reaches reports whether values of t can lead back to the struct named target, through a pointer or a map when indirect
+// is false. Types with a hand written Sanitize method are not followed, their method decides how deep it goes.
+func (g *generator) reaches(t *fieldType, target string, indirect bool, seen map[string]bool) bool {
+	if g.custom[t.name] {
+		return false
+	}
+
+	switch t.kind {
+	case kindStruct:
+		fields := t.fields
+		if t.name != "" {
+			if t.name == target {
+				return indirect
+			}
+
+			if seen[t.name] {
+				return false
+			}
+			seen[t.name] = true
+
+			fields = g.decls[t.name].Type.(*ast.StructType)
+		}
+
+		for _, field := range fields.Fields.List {
+			if g.reaches(g.resolve(field.Type, 0), target, indirect, seen) {
+				return true
+			}
+		}
+
+	case kindPointer, kindMap:
+		return g.reaches(t.elem, target, true, seen)
+
+	case kindSlice, kindArray:
+		return g.reaches(t.elem, target, indirect, seen)
+	}
+
+	return false
+}
+
+// tag returns the rules of a field as written in its struct tag
+func (g *generator) tag(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+
+	value, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+
+	return reflect.StructTag(value).Get(g.tagName)
+}
+
+// resolve turns a type expression into its sanitization shape
+func (g *generator) resolve(expr ast.Expr, depth int) *fieldType {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return &fieldType{kind: kindString}
+		case "any", "error":
+			return &fieldType{kind: kindInterface}
+		}
+
+		ts, ok := g.decls[e.Name]
+		if !ok || ts.TypeParams != nil || depth > 16 {
+			return &fieldType{kind: kindOther}
+		}
+
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			return &fieldType{kind: kindStruct, name: e.Name}
+		}
+
+		// Named types share the shape of their underlying type
+		t := *g.resolve(ts.Type, depth+1)
+		t.name = e.Name
+
+		return &t
+
+	case *ast.ParenExpr:
+		return g.resolve(e.X, depth)
+
+	case *ast.StarExpr:
+		return &fieldType{kind: kindPointer, elem: g.resolve(e.X, depth)}
+
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return &fieldType{kind: kindSlice, elem: g.resolve(e.Elt, depth)}
+		}
+
+		return &fieldType{kind: kindArray, elem: g.resolve(e.Elt, depth)}
+
+	case *ast.MapType:
+		return &fieldType{kind: kindMap, key: g.resolve(e.Key, depth), elem: g.resolve(e.Value, depth)}
+
+	case *ast.StructType:
+		return &fieldType{kind: kindStruct, fields: e}
+
+	case *ast.InterfaceType:
+		return &fieldType{kind: kindInterface}
+
+	case *ast.SelectorExpr:
+		return &fieldType{kind: kindExternal}
+	}
+
+	return &fieldType{kind: kindOther}
+}
+
+// method generates the Sanitize method of a struct
+func (g *generator) method(name string, st *ast.StructType) (string, error) {
+	// StructSanitizer stops cycles with the pointers it already walked, generated code has nowhere to keep them
+	for _, field := range st.Fields.List {
+		if g.reaches(g.resolve(field.Type, 0), name, false, map[string]bool{}) {
+			return "", g.errorf(field, "%s can hold itself through a pointer or a map and cycles would never end, use sanitizer.StructSanitizer", name)
+		}
+	}
+
+	code, err := g.fields("p", path{}, st)
+	if err != nil {
+		return "", err
+	}
+
+	// Hooks run around the fields, like StructSanitizer runs them around the struct given to SanitizeStruct
+	if g.hooks[name]["BeforeSanitize"] {
+		code = "if err := p.BeforeSanitize(); err != nil {\nerrs.Merge(\"\", err)\nreturn errs.Err()\n}\n\n" + code
+	}
+
+	if g.hooks[name]["AfterSanitize"] {
+		code += "\nerrs.Merge(\"\", p.AfterSanitize())\n"
+	}
+
+	return fmt.Sprintf(`
+// Sanitize cleans the fields of %[1]s based on their %[2]s tags
+func (p *%[1]s) Sanitize() error {
+	var errs sanitizer.Errors
+
+%[3]s
+	return errs.Err()
+}
+`, name, g.tagName, code), nil
+}
+
+// fields generates the code for every field of a struct reached through target
+func (g *generator) fields(target string, prefix path, st *ast.StructType) (string, error) {
+	var b strings.Builder
+
+	for _, field := range st.Fields.List {
+		rules, err := sanitizer.ParseTag(g.tag(field))
+		if err != nil {
+			return "", g.errorf(field, "%v", err)
+		}
+
+		t := g.resolve(field.Type, 0)
+
+		names := field.Names
+		embedded := len(names) == 0
+		if embedded {
+			names = []*ast.Ident{ast.NewIdent(embeddedName(field.Type))}
+		}
+
+		for _, ident := range names {
+			// StructSanitizer cannot set unexported fields, embedded structs still promote their fields
+			if !ident.IsExported() && !embedded && (len(rules) > 0 || g.needsWork(t)) {
+				return "", g.errorf(field, "field %s is unexported and cannot be sanitized", ident.Name)
+			}
+
+			code, err := g.value(target+"."+ident.Name, prefix.field(ident.Name), t, rules, field)
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(code)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// value generates the code sanitizing the addressable expression target of type t, with the hooks of its type around it
+func (g *generator) value(target string, p path, t *fieldType, rules []sanitizer.Rule, field *ast.Field) (string, error) {
+	hooks := g.hooks[t.name]
+	if hooks["SanitizeWith"] {
+		return "", g.errorf(field, "%s has a SanitizeWith method that needs the running sanitizer, use sanitizer.StructSanitizer", t.name)
+	}
+
+	code, err := g.walk(target, p, t, rules, field)
+
+	// Generated Sanitize methods call the hooks themselves
+	if err != nil || (g.methods[t.name] && !g.custom[t.name]) || !(hooks["BeforeSanitize"] || hooks["AfterSanitize"]) {
+		return code, err
+	}
+
+	if hooks["AfterSanitize"] {
+		code += fmt.Sprintf("errs.Merge(%s, %s.AfterSanitize())\n", p.expr(g), target)
+	}
+
+	// An error from BeforeSanitize leaves the value untouched
+	if hooks["BeforeSanitize"] {
+		code = fmt.Sprintf("if err := %s.BeforeSanitize(); err != nil {\nerrs.Merge(%s, err)\n} else {\n%s}\n", target, p.expr(g), code)
+	}
+
+	return code, nil
+}
+
+// walk generates the code sanitizing the addressable expression target of type t
+func (g *generator) walk(target string, p path, t *fieldType, rules []sanitizer.Rule, field *ast.Field) (string, error) {
+	// Types cleaning themselves replace the field rules, like with StructSanitizer
+	if g.custom[t.name] {
+		return fmt.Sprintf("errs.Merge(%s, %s.Sanitize())\n", p.expr(g), target), nil
+	}
+
+	switch t.kind {
+	case kindString:
+		return g.chain(target, p, t, rules, field)
+
+	case kindStruct:
+		if t.fields != nil {
+			return g.fields(target, p, t.fields)
+		}
+
+		if g.methods[t.name] {
+			return fmt.Sprintf("errs.Merge(%s, %s.Sanitize())\n", p.expr(g), target), nil
+		}
+
+	case kindPointer:
+		// Pointers to types with methods call them directly, other pointers are dereferenced
+		inner := "(*" + target + ")"
+		if (t.elem.kind == kindStruct && t.elem.fields == nil) || g.custom[t.elem.name] {
+			inner = target
+		}
+
+		code, err := g.value(inner, p, t.elem, rules, field)
+		if err != nil || code == "" {
+			return code, err
+		}
+
+		return fmt.Sprintf("if %s != nil {\n%s}\n", target, code), nil
+
+	case kindSlice, kindArray:
+		index := g.loopVar("i")
+		g.depth++
+		code, err := g.value(target+"["+index+"]", p.index(index, nil), t.elem, rules, field)
+		g.depth--
+		if err != nil || code == "" {
+			return code, err
+		}
+
+		return fmt.Sprintf("for %s := range %s {\n%s}\n", index, target, code), nil
+
+	case kindMap:
+		for _, r := range rules {
+			if r.Option == "keys" {
+				return "", g.errorf(field, "keys= rules are not supported by sanitizegen, use sanitizer.StructSanitizer")
+			}
+		}
+
+		key, value := g.loopVar("k"), g.loopVar("v")
+		g.depth++
+		code, err := g.value(value, p.index(key, t.key), t.elem, rules, field)
+		g.depth--
+		if err != nil || code == "" {
+			return code, err
+		}
+
+		return fmt.Sprintf("for %[1]s, %[2]s := range %[3]s {\n%[4]s%[3]s[%[1]s] = %[2]s\n}\n", key, value, target, code), nil
+
+	case kindInterface, kindExternal:
+		if len(rules) > 0 {
+			return "", g.errorf(field, "rules on %s are not supported by sanitizegen, use sanitizer.StructSanitizer", describe(t))
+		}
+	}
+
+	return "", nil
+}
+
+// chain generates the rule calls for a string, fallible rules keep the original value when they fail
+func (g *generator) chain(target string, p path, t *fieldType, rules []sanitizer.Rule, field *ast.Field) (string, error) {
+	// keys= rules only apply to map keys, StructSanitizer ignores them on other values
+	values := make([]sanitizer.Rule, 0, len(rules))
+	for _, r := range rules {
+		if r.Option != "keys" {
+			values = append(values, r)
+		}
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	current := target
+	if t.name != "" {
+		current = "string(" + target + ")"
+	}
+
+	var b strings.Builder
+	open := 0
+
+	for _, r := range values {
+		call, fallible, err := ruleCall(r, current)
+		if err != nil {
+			return "", g.errorf(field, "%v", err)
+		}
+
+		if !fallible {
+			current = call
+			continue
+		}
+
+		sanitized := "sanitized"
+		if open > 0 {
+			sanitized = fmt.Sprintf("sanitized%d", open+1)
+		}
+
+		fmt.Fprintf(&b, "if %s, err := %s; err != nil {\nerrs.Add(%s, %q, err)\n} else {\n", sanitized, call, p.expr(g), r.String())
+		current = sanitized
+		open++
+	}
+
+	if t.name != "" {
+		current = t.name + "(" + current + ")"
+	}
+
+	fmt.Fprintf(&b, "%s = %s\n", target, current)
+	b.WriteString(strings.Repeat("}\n", open))
+
+	return b.String(), nil
+}
+
+// loopVar names the loop variables so nested loops do not shadow each other
+func (g *generator) loopVar(name string) string {
+	if g.depth == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s%d", name, g.depth+1)
+}
+
+// errorf reports a generation error at the position of a field
+func (g *generator) errorf(field *ast.Field, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", g.fset.Position(field.Pos()), fmt.Sprintf(format, args...))
+}
+
+// hookParams is the number of parameters of the methods StructSanitizer calls
+var hookParams = map[string]int{
+	"Sanitize":       0,
+	"SanitizeWith":   1,
+	"BeforeSanitize": 0,
+	"AfterSanitize":  0,
+}
+
+// addMethod records a hand written Sanitize, SanitizeWith, BeforeSanitize or AfterSanitize method
+func (g *generator) addMethod(fn *ast.FuncDecl) {
+	params, ok := hookParams[fn.Name.Name]
+	if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Type.Params.NumFields() != params {
+		return
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+
+	ident, ok := recv.(*ast.Ident)
+	if !ok {
+		return
+	}
+
+	if fn.Name.Name == "Sanitize" {
+		g.custom[ident.Name] = true
+		return
+	}
+
+	if g.hooks[ident.Name] == nil {
+		g.hooks[ident.Name] = map[string]bool{}
+	}
+	g.hooks[ident.Name][fn.Name.Name] = true
+}
+
+// embeddedName returns the field name of an embedded type
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+
+	return ""
+}
+
+// describe names a kind in error messages
+func describe(t *fieldType) string {
+	if t.kind == kindInterface {
+		return "interface fields"
+	}
+
+	return "types from other packages"
+}
+
+// path builds the Go expression of a field path like "Tags[" + strconv.Itoa(i) + "]"
+type path struct {
+	parts []pathPart
+}
+
+// pathPart is either literal text or a loop variable
+type pathPart struct {
+	text string
+	loop bool
+	key  *fieldType // type of the map key held by the loop variable, nil for slice indexes
+}
+
+// field appends a struct field name
+func (p path) field(name string) path {
+	if len(p.parts) > 0 {
+		name = "." + name
+	}
+
+	return p.with(pathPart{text: name})
+}
+
+// index appends a slice index or a map key held by the loop variable name
+func (p path) index(name string, key *fieldType) path {
+	return p.with(pathPart{text: "["}, pathPart{text: name, loop: true, key: key}, pathPart{text: "]"})
+}
+
+func (p path) with(parts ...pathPart) path {
+	return path{parts: append(append([]pathPart(nil), p.parts...), parts...)}
+}
+
+// expr renders the path, registering the imports it needs
+func (p path) expr(g *generator) string {
+	var exprs []string
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			exprs = append(exprs, strconv.Quote(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	for _, part := range p.parts {
+		if !part.loop {
+			literal.WriteString(part.text)
+			continue
+		}
+
+		flush()
+
+		switch {
+		case part.key == nil:
+			g.imports["strconv"] = true
+			exprs = append(exprs, "strconv.Itoa("+part.text+")")
+		case part.key.kind == kindString && part.key.name == "":
+			exprs = append(exprs, part.text)
+		case part.key.kind == kindString:
+			exprs = append(exprs, "string("+part.text+")")
+		default:
+			g.imports["fmt"] = true
+			exprs = append(exprs, "fmt.Sprint("+part.text+")")
+		}
+	}
+	flush()
+
+	return strings.Join(exprs, " + ")
+}
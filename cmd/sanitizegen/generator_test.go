@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePackage writes a single file package into a temporary directory
+func writePackage(t *testing.T, src string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "types.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestGenerateExamples(t *testing.T) {
+	g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	got, err := g.generate("../../examples")
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	want, err := os.ReadFile("../../examples/sanitize_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("examples/sanitize_gen.go is out of date, run go generate ./examples\n%s", got)
+	}
+}
+
+func TestGenerateKinds(t *testing.T) {
+	dir := writePackage(t, "package kinds\n\n"+
+		"type Email string\n\n"+
+		"type Item struct {\n"+
+		"\tName string `sanitize:\"html\"`\n"+
+		"}\n\n"+
+		"type Order struct {\n"+
+		"\tEmail    Email              `sanitize:\"xss\"`\n"+
+		"\tNote     *string            `sanitize:\"html, truncate(10)\"`\n"+
+		"\tTags     []string           `sanitize:\"alpha(spaces=false)\"`\n"+
+		"\tCodes    [2]Email           `sanitize:\"alphanumeric\"`\n"+
+		"\tMatrix   [][]string         `sanitize:\"html\"`\n"+
+		"\tMeta     map[string]string  `sanitize:\"url(www=keep), xss\"`\n"+
+		"\tCounts   map[int][]string   `sanitize:\"url\"`\n"+
+		"\tItem     Item\n"+
+		"\tItems    []*Item\n"+
+		"\tByName   map[Email]Item\n"+
+		"\tInline   struct {\n"+
+		"\t\tCity string `sanitize:\"xml\"`\n"+
+		"\t}\n"+
+		"\tComment  string `sanitize:\"html(policy=ugc)\"`\n"+
+		"\tMarkup   string `sanitize:\"scripts, attrs\"`\n"+
+		"\tSite     string `sanitize:\"domain(www=keep)\"`\n"+
+		"\tHome     string `sanitize:\"url(removeWww=false, www=remove)\"`\n"+
+		"\tHost     string `sanitize:\"domain(homograph=reject)\"`\n"+
+		"\tLabel    string `sanitize:\"keys=alpha, html\"`\n"+
+		"\tKeyOnly  string `sanitize:\"keys=alpha\"`\n"+
+		"\tEscaped  string `sanitize:\"html_attr_escape, js_escape, css_escape, url_escape, json_script_escape\"`\n"+
+		"\tText     string `sanitize:\"html(decode=true), xml(decode=true)\"`\n"+
+		"\tAge      int `sanitize:\"xss\"`\n"+
+		"\tUntagged any\n"+
+		"}\n\n"+
+		"type Wrapper struct {\n"+
+		"\tOrder *Order\n"+
+		"}\n\n"+
+		"type Plain struct {\n"+
+		"\tName string\n"+
+		"}\n")
+
+	g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	got, err := g.generate(dir)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	src := string(got)
+	for _, want := range []string{
+		"func (p *Item) Sanitize() error",
+		"func (p *Order) Sanitize() error",
+		"func (p *Wrapper) Sanitize() error",
+		`"strconv"`,
+		`"fmt"`,
+		"p.Email = Email(sanitizer.XSS(string(p.Email)))",
+		"if p.Note != nil {\n\t\t(*p.Note) = sanitizer.Truncate(sanitizer.HTML((*p.Note)), 10)\n\t}",
+		"for i := range p.Tags {\n\t\tp.Tags[i] = sanitizer.Alpha(p.Tags[i], false)\n\t}",
+		"p.Codes[i] = Email(sanitizer.AlphaNumeric(string(p.Codes[i]), true))",
+		"for i2 := range p.Matrix[i] {\n\t\t\tp.Matrix[i][i2] = sanitizer.HTML(p.Matrix[i][i2])",
+		"if sanitized, err := sanitizer.URL(v, false); err != nil {\n\t\t\terrs.Add(\"Meta[\"+k+\"]\", \"url(www=keep)\", err)\n\t\t} else {\n\t\t\tv = sanitizer.XSS(sanitized)\n\t\t}\n\t\tp.Meta[k] = v",
+		"p.Counts[k] = v",
+		"fmt.Sprint(k)",
+		"strconv.Itoa(i2)",
+		`errs.Merge("Item", p.Item.Sanitize())`,
+		"if p.Items[i] != nil {\n\t\t\terrs.Merge(\"Items[\"+strconv.Itoa(i)+\"]\", p.Items[i].Sanitize())",
+		`errs.Merge("ByName["+string(k)+"]", v.Sanitize())`,
+		"p.Inline.City = sanitizer.XML(p.Inline.City)",
+		"p.Comment = sanitizer.UGCPolicy().Sanitize(p.Comment)",
+		"p.Markup = sanitizer.Attributes(sanitizer.Scripts(p.Markup))",
+		"if sanitized, err := sanitizer.Domain(p.Site, false); err != nil {\n\t\terrs.Add(\"Site\", \"domain(www=keep)\", err)\n\t} else {\n\t\tp.Site = sanitized\n\t}",
+		"sanitizer.URL(p.Home, true)",
+		"if sanitized, err := sanitizer.RejectHomograph(sanitizer.Domain(p.Host, true)); err != nil {\n\t\terrs.Add(\"Host\", \"domain(homograph=reject)\", err)",
+		"p.Label = sanitizer.HTML(p.Label)",
+		"p.Escaped = sanitizer.EscapeJSONForScript(sanitizer.EscapeURLComponent(sanitizer.EscapeCSSString(sanitizer.EscapeJSString(sanitizer.EscapeHTMLAttr(p.Escaped)))))",
+		"p.Text = sanitizer.XMLText(sanitizer.HTMLText(p.Text))",
+		"if p.Order != nil {\n\t\terrs.Merge(\"Order\", p.Order.Sanitize())",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code is missing %q\n%s", want, src)
+		}
+	}
+
+	for _, unwanted := range []string{"Plain", "p.Age", "Untagged", "KeyOnly", "sanitizer.Alpha(p.Label"} {
+		if strings.Contains(src, unwanted) {
+			t.Errorf("generated code should not contain %q\n%s", unwanted, src)
+		}
+	}
+}
+
+func TestGenerateCustomMethods(t *testing.T) {
+	dir := writePackage(t, "package custom\n\n"+
+		"type Money string\n\n"+
+		"func (m *Money) Sanitize() error { return nil }\n\n"+
+		"type Address struct {\n"+
+		"\tStreet string `sanitize:\"html\"`\n"+
+		"}\n\n"+
+		"func (a Address) Sanitize() error { return nil }\n\n"+
+		"type Order struct {\n"+
+		"\tTotal   Money `sanitize:\"xss\"`\n"+
+		"\tPrices  []*Money\n"+
+		"\tAddress Address\n"+
+		"}\n")
+
+	g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	got, err := g.generate(dir)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	src := string(got)
+	for _, want := range []string{
+		"func (p *Order) Sanitize() error",
+		`errs.Merge("Total", p.Total.Sanitize())`,
+		"if p.Prices[i] != nil {\n\t\t\terrs.Merge(\"Prices[\"+strconv.Itoa(i)+\"]\", p.Prices[i].Sanitize())",
+		`errs.Merge("Address", p.Address.Sanitize())`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code is missing %q\n%s", want, src)
+		}
+	}
+
+	for _, unwanted := range []string{"func (p *Money)", "func (p *Address)"} {
+		if strings.Contains(src, unwanted) {
+			t.Errorf("generated code should not contain %q\n%s", unwanted, src)
+		}
+	}
+}
+
+func TestGenerateSanitizeWith(t *testing.T) {
+	src := "package with\n\n" +
+		"import \"github.com/JoshuaJimenezR/sanitizer\"\n\n" +
+		"type Addr struct {\n" +
+		"\tStreet string `sanitize:\"html\"`\n" +
+		"}\n\n" +
+		"func (a *Addr) SanitizeWith(st *sanitizer.StructSanitizer) error { return st.SanitizeStruct(a) }\n\n" +
+		"type Name struct {\n" +
+		"\tFirst string `sanitize:\"html\"`\n" +
+		"}\n"
+
+	g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	// SanitizeWith replaces the walk, so Addr gets no generated Sanitize method
+	got, err := g.generate(writePackage(t, src))
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	if src := string(got); strings.Contains(src, "func (p *Addr)") || !strings.Contains(src, "func (p *Name)") {
+		t.Errorf("generated code should only sanitize Name\n%s", src)
+	}
+
+	// Without a StructSanitizer to give it, structs holding Addr cannot be generated
+	g = &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	_, err = g.generate(writePackage(t, src+"\ntype Order struct {\n\tAddr Addr\n}\n"))
+	if err == nil || !strings.Contains(err.Error(), "Addr has a SanitizeWith method") {
+		t.Errorf("generate() error = %v, want a SanitizeWith error", err)
+	}
+}
+
+func TestGenerateRecursiveTypes(t *testing.T) {
+	src := "package recursive\n\n" +
+		"type Team struct {\n" +
+		"\tName    string `sanitize:\"html\"`\n" +
+		"\tMembers []Member\n" +
+		"}\n\n" +
+		"type Member struct {\n" +
+		"\tName string `sanitize:\"html\"`\n" +
+		"\tTeam *Team\n" +
+		"}\n"
+
+	g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	_, err := g.generate(writePackage(t, src))
+	if err == nil || !strings.Contains(err.Error(), "Team can hold itself through a pointer or a map") ||
+		!strings.Contains(err.Error(), "use sanitizer.StructSanitizer") {
+		t.Errorf("generate() error = %v, want a recursive type error", err)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{name: "Unknown rule", field: "Name string `sanitize:\"trim\"`", want: "unknown rule trim"},
+		{name: "Malformed tag", field: "Name string `sanitize:\"alpha(spaces\"`", want: "missing closing parenthesis"},
+		{name: "Invalid argument", field: "Name string `sanitize:\"alpha(spaces=maybe)\"`", want: "not a boolean"},
+		{name: "Unknown argument", field: "Name string `sanitize:\"url(scheme=http)\"`", want: "unknown argument scheme"},
+		{name: "Invalid homograph argument", field: "Name string `sanitize:\"url(homograph=warn)\"`", want: "homograph=warn must be allow or reject"},
+		{name: "Invalid www argument", field: "Name string `sanitize:\"url(www=drop)\"`", want: "www=drop must be keep or remove"},
+		{name: "Arguments on a rule without arguments", field: "Name string `sanitize:\"xss(1)\"`", want: "does not take arguments"},
+		{name: "Invalid truncate length", field: "Name string `sanitize:\"truncate(-1)\"`", want: "positive integer"},
+		{name: "Unknown policy", field: "Name string `sanitize:\"html(policy=cms)\"`", want: "policy must be strict or ugc"},
+		{name: "Decoding with a policy", field: "Name string `sanitize:\"html(policy=ugc, decode=true)\"`", want: "decode cannot be used with policy"},
+		{name: "Reject flag", field: "Name string `sanitize:\"xss, reject\"`", want: "reject is not supported"},
+		{name: "Key rules", field: "Meta map[string]string `sanitize:\"keys=alpha\"`", want: "keys= rules are not supported"},
+		{name: "Interface field", field: "Body any `sanitize:\"xss\"`", want: "interface fields"},
+		{name: "External type", field: "Created time.Time `sanitize:\"xss\"`", want: "types from other packages"},
+		{name: "Unexported field", field: "name string `sanitize:\"xss\"`", want: "field name is unexported"},
+		{name: "Self referencing pointer", field: "Next *Payload `sanitize:\"xss\"`", want: "Payload can hold itself through a pointer or a map"},
+		{name: "Self referencing map", field: "Children map[string][]Payload\n\tName string `sanitize:\"xss\"`", want: "Payload can hold itself"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package errs\n\n"
+			if strings.Contains(tt.field, "time.") {
+				src += "import \"time\"\n\n"
+			}
+			src += "type Payload struct {\n\t" + tt.field + "\n}\n"
+
+			g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+			_, err := g.generate(writePackage(t, src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("generate() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateNoTags(t *testing.T) {
+	g := &generator{tagName: "sanitize", output: "sanitize_gen.go"}
+
+	if _, err := g.generate(writePackage(t, "package plain\n\ntype Plain struct {\n\tName string\n}\n")); err == nil {
+		t.Errorf("generate() expected error without tagged structs")
+	}
+}
@@ -0,0 +1,47 @@
+// Command sanitizegen generates reflection-free Sanitize methods for structs with sanitize tags.
+//
+// Add a go:generate directive to the package holding the structs:
+//
+//	//go:generate go run github.com/JoshuaJimenezR/sanitizer/cmd/sanitizegen
+//
+// Every struct with at least one tagged field, or holding such a struct, gets a
+// Sanitize() error method that calls the sanitizer functions directly and
+// behaves like sanitizer.StructSanitizer, BeforeSanitize and AfterSanitize
+// methods included. Unknown rules, structs holding a type with a SanitizeWith
+// method and structs holding themselves through a pointer or a map fail the
+// generation.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	tagName := flag.String("tag", "sanitize", "struct tag holding the rules")
+	output := flag.String("output", "sanitize_gen.go", "output file name, relative to the package directory")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	g := &generator{
+		tagName: *tagName,
+		output:  *output,
+	}
+
+	src, err := g.generate(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sanitizegen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "sanitizegen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/JoshuaJimenezR/sanitizer"
+)
+
+// ruleCall returns the call applying a built-in rule to the expression in, fallible calls return (string, error)
+func ruleCall(r sanitizer.Rule, in string) (call string, fallible bool, err error) {
+	switch r.Name {
+	case "html":
+		return htmlCall(r, in)
+	case "xml":
+		decode, err := boolArg(r, "decode", false)
+		if err != nil {
+			return "", false, err
+		}
+
+		if decode {
+			return "sanitizer.XMLText(" + in + ")", false, nil
+		}
+
+		return "sanitizer.XML(" + in + ")", false, nil
+	case "html_escape":
+		return "sanitizer.HtmlEscape(" + in + ")", false, noArgs(r)
+	case "scripts":
+		return "sanitizer.Scripts(" + in + ")", false, noArgs(r)
+	case "uri":
+		return "sanitizer.URI(" + in + ")", false, noArgs(r)
+	case "xss":
+		return "sanitizer.XSS(" + in + ")", false, noArgs(r)
+	case "attrs":
+		return "sanitizer.Attributes(" + in + ")", false, noArgs(r)
+	case "html_attr_escape":
+		return "sanitizer.EscapeHTMLAttr(" + in + ")", false, noArgs(r)
+	case "js_escape":
+		return "sanitizer.EscapeJSString(" + in + ")", false, noArgs(r)
+	case "css_escape":
+		return "sanitizer.EscapeCSSString(" + in + ")", false, noArgs(r)
+	case "url_escape":
+		return "sanitizer.EscapeURLComponent(" + in + ")", false, noArgs(r)
+	case "json_script_escape":
+		return "sanitizer.EscapeJSONForScript(" + in + ")", false, noArgs(r)
+
+	case "alpha", "alphanumeric":
+		spaces, err := boolArg(r, "spaces", true)
+		if err != nil {
+			return "", false, err
+		}
+
+		fn := "Alpha"
+		if r.Name == "alphanumeric" {
+			fn = "AlphaNumeric"
+		}
+
+		return fmt.Sprintf("sanitizer.%s(%s, %t)", fn, in, spaces), false, nil
+
+	case "url", "domain":
+		removeWww, rejectHomograph, err := urlArgs(r)
+		if err != nil {
+			return "", false, err
+		}
+
+		fn := "URL"
+		if r.Name == "domain" {
+			fn = "Domain"
+		}
+
+		call := fmt.Sprintf("sanitizer.%s(%s, %t)", fn, in, removeWww)
+		if rejectHomograph {
+			call = "sanitizer.RejectHomograph(" + call + ")"
+		}
+
+		return call, true, nil
+
+	case "truncate":
+		if len(r.Args.Named) > 0 || len(r.Args.Positional) != 1 {
+			return "", false, fmt.Errorf("rule %s: expected a single length argument", r)
+		}
+
+		length, err := strconv.Atoi(r.Args.Positional[0])
+		if err != nil || length < 0 {
+			return "", false, fmt.Errorf("rule %s: length must be a positive integer", r)
+		}
+
+		return fmt.Sprintf("sanitizer.Truncate(%s, %d)", in, length), false, nil
+	}
+
+	if r.Name == "reject" {
+		return "", false, fmt.Errorf("reject is not supported by sanitizegen, use sanitizer.StructSanitizer")
+	}
+
+	return "", false, fmt.Errorf("unknown rule %s, only built-in rules can be generated", r.Name)
+}
+
+// htmlCall returns the call for html, html(decode=true) or html(policy=ugc)
+func htmlCall(r sanitizer.Rule, in string) (string, bool, error) {
+	for key := range r.Args.Named {
+		if key != "policy" && key != "decode" {
+			return "", false, fmt.Errorf("rule %s: unknown argument %s", r, key)
+		}
+	}
+
+	if len(r.Args.Positional) > 0 {
+		return "", false, fmt.Errorf("rule %s does not take positional arguments", r)
+	}
+
+	decode, err := r.Args.Bool("decode", false)
+	if err != nil {
+		return "", false, fmt.Errorf("rule %s: %v", r, err)
+	}
+
+	switch policy, ok := r.Args.Named["policy"]; {
+	case ok && decode:
+		return "", false, fmt.Errorf("rule %s: argument decode cannot be used with policy", r)
+	case !ok && decode:
+		return "sanitizer.HTMLText(" + in + ")", false, nil
+	case !ok:
+		return "sanitizer.HTML(" + in + ")", false, nil
+	case policy == "strict":
+		return "sanitizer.StrictPolicy().Sanitize(" + in + ")", false, nil
+	case policy == "ugc":
+		return "sanitizer.UGCPolicy().Sanitize(" + in + ")", false, nil
+	}
+
+	return "", false, fmt.Errorf("rule %s: policy must be strict or ugc", r)
+}
+
+// noArgs rejects arguments on rules that take none
+func noArgs(r sanitizer.Rule) error {
+	if !r.Args.Empty() {
+		return fmt.Errorf("rule %s does not take arguments", r.Name)
+	}
+
+	return nil
+}
+
+// boolArg reads the only named boolean argument a rule accepts
+func boolArg(r sanitizer.Rule, key string, def bool) (bool, error) {
+	if len(r.Args.Positional) > 0 {
+		return def, fmt.Errorf("rule %s: unexpected positional arguments", r)
+	}
+
+	for name := range r.Args.Named {
+		if name != key {
+			return def, fmt.Errorf("rule %s: unknown argument %s", r, name)
+		}
+	}
+
+	value, err := r.Args.Bool(key, def)
+	if err != nil {
+		return def, fmt.Errorf("rule %s: %v", r, err)
+	}
+
+	return value, nil
+}
+
+// urlArgs reads the www=keep|remove or removeWww=bool and the homograph=allow|reject arguments of url and domain
+func urlArgs(r sanitizer.Rule) (removeWww bool, rejectHomograph bool, err error) {
+	if len(r.Args.Positional) > 0 {
+		return false, false, fmt.Errorf("rule %s: unexpected positional arguments", r)
+	}
+
+	for name := range r.Args.Named {
+		if name != "removeWww" && name != "www" && name != "homograph" {
+			return false, false, fmt.Errorf("rule %s: unknown argument %s", r, name)
+		}
+	}
+
+	// removeWww is read first so www wins when both are given, like the url rule of the registry
+	removeWww, err = r.Args.Bool("removeWww", true)
+	if err != nil {
+		return false, false, fmt.Errorf("rule %s: %v", r, err)
+	}
+
+	switch www := r.Args.String("www", ""); www {
+	case "":
+	case "keep":
+		removeWww = false
+	case "remove":
+		removeWww = true
+	default:
+		return false, false, fmt.Errorf("rule %s: argument www=%s must be keep or remove", r, www)
+	}
+
+	switch homograph := r.Args.String("homograph", "allow"); homograph {
+	case "allow":
+	case "reject":
+		rejectHomograph = true
+	default:
+		return false, false, fmt.Errorf("rule %s: argument homograph=%s must be allow or reject", r, homograph)
+	}
+
+	return removeWww, rejectHomograph, nil
+}
@@ -8,28 +8,53 @@ var (
 	alphaWithSpacesRegex        = regexp.MustCompile("[^a-zA-Z ]+")    // alpha characters with spaces
 	alphaNumericRegex           = regexp.MustCompile("[^a-zA-Z0-9]+")  // alphanumeric characters
 	alphaNumericWithSpacesRegex = regexp.MustCompile("[^a-zA-Z0-9 ]+") // alphanumeric characters with spaces
-	htmlRegex                   = regexp.MustCompile(`<[^>]*>`)        // html/xml tags or any alligator open/close tags
 	wwwRegex                    = regexp.MustCompile(`(?i)www.`)       // removing www
 
 	urlRegex = regexp.MustCompile(`^(?:https?://)?(?:www\.)?[a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)*(?::\d+)?(?:/\S*)?$`) // url allowed characters and prevent attacks
 
+	domainLabelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`) // RFC 1123 host name label
+
 	uriRegex = regexp.MustCompile(`[^:/?#\[\]@!$&'()*+,;=a-zA-Z0-9_~.%-]+`) // uri allowed characters
 
-	scriptsRegex = regexp.MustCompile(`(?i)<(script|iframe|embed|object)[^>]*>.*</(script|iframe|embed|object)>`) // Harmful script tags
+	xssEvalRegex         = regexp.MustCompile(`(?i)\beval\s*\(`)
+	xssJavascriptRegex   = regexp.MustCompile(`(?i)javascript:`)
+	xssScriptURIRegex    = regexp.MustCompile(`(?i)(vbscript|livescript):`)
+	xssDataURIRegex      = regexp.MustCompile(`(?i)data:\s*text/html`)
+	xssTimerRegex        = regexp.MustCompile("(?i)set(Timeout|Interval)\\s*\\(\\s*[\"'`]") // timers given a string of code
+	xssFunctionRegex     = regexp.MustCompile(`\bFunction\s*\(`)                            // the Function constructor, function( is left alone
+	xssFromCharCodeRegex = regexp.MustCompile(`(?i)fromCharCode`)
+
+	cssCommentRegex = regexp.MustCompile(`(?s)/\*.*?(\*/|$)`)                                                     // css comments, unterminated ones run to the end
+	cssEscapeRegex  = regexp.MustCompile(`\\([0-9a-fA-F]{1,6}[ \t\n\f\r]?|[^0-9a-fA-F\n])`)                       // css escapes like \65 or \(
+	cssThreatRegex  = regexp.MustCompile(`expression\(|(javascript|vbscript|livescript):|behavior:|-moz-binding`) // css running code
 
-	xssEvalRegex          = regexp.MustCompile(`(?i)eval[\(\&]`)
-	xssJavascriptRegex    = regexp.MustCompile(`(?i)javascript[\:\&]`)
-	xssFromCharCodeRegex  = regexp.MustCompile(`(?i)fromCharCode`)
+	entityRegex   = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);?`)               // html entity at the start of the input
+	jsEscapeRegex = regexp.MustCompile(`^\\(?:x([0-9a-fA-F]{2})|u([0-9a-fA-F]{4})|u\{([0-9a-fA-F]{1,6})\})`) // javascript escape at the start of the input
 
 	emptySpace = ""
 
+	defaultTagName = "sanitize" // struct tag used when none is given
+	ruleSeparator  = ","        // separates chained rules in a struct tag
+	keysOption     = "keys"     // tag option for rules applied to map keys
+	valuesOption   = "values"   // tag option for rules applied to map values
+	rejectOption   = "reject"   // tag flag rejecting values the rules would change
+
 	htmlField         = "html"
 	xmlField          = "xml"
 	htmlEscapeField   = "html_escape"
 	scriptsField      = "scripts"
 	urlField          = "url"
+	domainField       = "domain"
 	uriField          = "uri"
 	alphaField        = "alpha"
 	alphanumericField = "alphanumeric"
 	xssField          = "xss"
+	attrsField        = "attrs"
+	truncateField     = "truncate"
+
+	htmlAttrEscapeField   = "html_attr_escape"
+	jsEscapeField         = "js_escape"
+	cssEscapeField        = "css_escape"
+	urlEscapeField        = "url_escape"
+	jsonScriptEscapeField = "json_script_escape"
 )
@@ -0,0 +1,102 @@
+package sanitizer
+
+import "reflect"
+
+// copyKey identifies a pointer or a map already copied, so shared and cyclic values are copied once
+type copyKey struct {
+	t reflect.Type
+	p uintptr
+}
+
+// copier deep copies values, it remembers the copies of pointers and maps
+type copier map[copyKey]reflect.Value
+
+// deepCopy returns an addressable copy of v that shares no memory the walker can write to,
+// values behind unexported fields cannot be copied and stay shared, the walker leaves them untouched
+func deepCopy(v reflect.Value) reflect.Value {
+	return copier{}.copyOf(v)
+}
+
+// copyOf returns an addressable deep copy of v
+func (c copier) copyOf(v reflect.Value) reflect.Value {
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+
+	c.replace(copied)
+
+	return copied
+}
+
+// replace swaps the pointers, slices, maps and interfaces inside the settable value v for copies
+func (c copier) replace(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+
+		key := copyKey{t: v.Type(), p: v.Pointer()}
+		if copied, ok := c[key]; ok {
+			v.Set(copied)
+			return
+		}
+
+		copied := reflect.New(v.Type().Elem())
+		c[key] = copied
+
+		copied.Elem().Set(v.Elem())
+		c.replace(copied.Elem())
+		v.Set(copied)
+
+	case reflect.Struct:
+		// Unexported fields cannot be set, exported fields of embedded structs still can
+		for i := 0; i < v.NumField(); i++ {
+			c.replace(v.Field(i))
+		}
+
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.replace(v.Index(i))
+		}
+
+	case reflect.Slice:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+
+		for i := 0; i < copied.Len(); i++ {
+			c.replace(copied.Index(i))
+		}
+		v.Set(copied)
+
+	case reflect.Map:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+
+		key := copyKey{t: v.Type(), p: v.Pointer()}
+		if copied, ok := c[key]; ok {
+			v.Set(copied)
+			return
+		}
+
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		c[key] = copied
+
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(c.copyOf(iter.Key()), c.copyOf(iter.Value()))
+		}
+		v.Set(copied)
+
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+
+		v.Set(c.copyOf(v.Elem()))
+	}
+}
@@ -0,0 +1,141 @@
+package sanitizer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Category is the kind of threat reported by Detect
+type Category string
+
+const (
+	CategoryScriptTag       Category = "script_tag"            // script, iframe, svg and the other elements Scripts removes
+	CategoryJavascriptURI   Category = "javascript_uri"        // javascript: URIs
+	CategoryScriptURI       Category = "script_uri"            // vbscript: and livescript: URIs
+	CategoryDataURI         Category = "data_uri"              // data:text/html URIs
+	CategoryEval            Category = "eval_call"             // eval( calls
+	CategoryStringExecution Category = "string_execution"      // setTimeout and setInterval given a string, the Function constructor
+	CategoryFromCharCode    Category = "from_char_code"        // String.fromCharCode obfuscation
+	CategoryEncodedBrackets Category = "encoded_angle_bracket" // < and > written as entities, percent encoding or JS escapes
+)
+
+// Severity ranks how dangerous a finding is
+type Severity int
+
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+)
+
+// String returns the name of the severity, e.g. high
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	}
+
+	return "unknown"
+}
+
+// MarshalText writes the severity by name, so findings serialize as "severity":"high"
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Finding is a threat found in the input, Start and End are byte offsets so input[Start:End] is Match.
+// Match is written as in the input, e.g. jav&#x61;script: for a javascript: URI
+type Finding struct {
+	Category Category `json:"category"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	Match    string   `json:"match"`
+	Severity Severity `json:"severity"`
+}
+
+// detector finds a single category of threats
+type detector struct {
+	category Category
+	severity Severity
+	regex    *regexp.Regexp
+}
+
+// detectors run in order on the decoded input after the dangerous elements are found, findings at the same offset keep this order
+var detectors = []detector{
+	{category: CategoryJavascriptURI, severity: SeverityHigh, regex: xssJavascriptRegex},
+	{category: CategoryScriptURI, severity: SeverityHigh, regex: xssScriptURIRegex},
+	{category: CategoryDataURI, severity: SeverityHigh, regex: xssDataURIRegex},
+	{category: CategoryEval, severity: SeverityMedium, regex: xssEvalRegex},
+	{category: CategoryStringExecution, severity: SeverityMedium, regex: xssTimerRegex},
+	{category: CategoryStringExecution, severity: SeverityMedium, regex: xssFunctionRegex},
+	{category: CategoryFromCharCode, severity: SeverityMedium, regex: xssFromCharCodeRegex},
+}
+
+// Detect reports the threats XSS and Scripts would remove without changing the input,
+// findings are ordered by offset
+func Detect(input string) []Finding {
+	var findings []Finding
+
+	if strings.Contains(input, "<") {
+		for _, span := range scriptSpans(input) {
+			findings = append(findings, Finding{
+				Category: CategoryScriptTag,
+				Start:    span[0],
+				End:      span[1],
+				Match:    input[span[0]:span[1]],
+				Severity: SeverityHigh,
+			})
+		}
+	}
+
+	findings = append(findings, threats(input)...)
+
+	sortFindings(findings)
+
+	return findings
+}
+
+// sortFindings orders findings by offset, findings at the same offset keep their order
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+}
+
+// threats runs the detectors on input with its entities, percent encoding and JS escapes decoded,
+// the findings point back to the input as written
+func threats(input string) []Finding {
+	var findings []Finding
+
+	c := canonicalize(input)
+	add := func(category Category, severity Severity, start, end int) {
+		span := c.span(start, end)
+		findings = append(findings, Finding{
+			Category: category,
+			Start:    span[0],
+			End:      span[1],
+			Match:    input[span[0]:span[1]],
+			Severity: severity,
+		})
+	}
+
+	for _, d := range detectors {
+		for _, loc := range d.regex.FindAllStringIndex(c.text, -1) {
+			add(d.category, d.severity, loc[0], loc[1])
+		}
+	}
+
+	// A < or > read from more than one byte was encoded
+	for i := 0; i < len(c.text); i++ {
+		if (c.text[i] == '<' || c.text[i] == '>') && c.origins[i][1]-c.origins[i][0] > 1 {
+			add(CategoryEncodedBrackets, SeverityLow, i, i+1)
+		}
+	}
+
+	return findings
+}
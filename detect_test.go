@@ -0,0 +1,112 @@
+package sanitizer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Finding
+	}{
+		{name: "Clean input", input: "Hello world, 3 < 4", want: nil},
+		{name: "Words ending in eval", input: "Retrieval (RAG) works in the medieval (12th c.) period", want: nil},
+		{
+			name:  "Script block",
+			input: `Hi <script>alert(1)</script>`,
+			want:  []Finding{{Category: CategoryScriptTag, Start: 3, End: 28, Match: "<script>alert(1)</script>", Severity: SeverityHigh}},
+		},
+		{
+			name:  "Unclosed script tag",
+			input: `Hi <SCRIPT src="x.js">`,
+			want:  []Finding{{Category: CategoryScriptTag, Start: 3, End: 22, Match: `<SCRIPT src="x.js">`, Severity: SeverityHigh}},
+		},
+		{
+			name:  "Svg over several lines",
+			input: "<svg>\n<script>x()</script>\n</svg>ok",
+			want:  []Finding{{Category: CategoryScriptTag, Start: 0, End: 33, Match: "<svg>\n<script>x()</script>\n</svg>", Severity: SeverityHigh}},
+		},
+		{
+			name:  "Javascript URI",
+			input: `<a href="javascript:go()">`,
+			want:  []Finding{{Category: CategoryJavascriptURI, Start: 9, End: 20, Match: "javascript:", Severity: SeverityHigh}},
+		},
+		{
+			name:  "Eval and fromCharCode",
+			input: `eval(String.fromCharCode(97))`,
+			want: []Finding{
+				{Category: CategoryEval, Start: 0, End: 5, Match: "eval(", Severity: SeverityMedium},
+				{Category: CategoryFromCharCode, Start: 12, End: 24, Match: "fromCharCode", Severity: SeverityMedium},
+			},
+		},
+		{
+			name:  "Encoded angle brackets",
+			input: `&lt;b&gt; &#60;i&#62; &#x3C;`,
+			want: []Finding{
+				{Category: CategoryEncodedBrackets, Start: 0, End: 4, Match: "&lt;", Severity: SeverityLow},
+				{Category: CategoryEncodedBrackets, Start: 5, End: 9, Match: "&gt;", Severity: SeverityLow},
+				{Category: CategoryEncodedBrackets, Start: 10, End: 15, Match: "&#60;", Severity: SeverityLow},
+				{Category: CategoryEncodedBrackets, Start: 16, End: 21, Match: "&#62;", Severity: SeverityLow},
+				{Category: CategoryEncodedBrackets, Start: 22, End: 28, Match: "&#x3C;", Severity: SeverityLow},
+			},
+		},
+		{
+			name:  "Obfuscated URIs",
+			input: `<a href="jav&#x61;script:x"><a href="vb%73cript:x">`,
+			want: []Finding{
+				{Category: CategoryJavascriptURI, Start: 9, End: 25, Match: "jav&#x61;script:", Severity: SeverityHigh},
+				{Category: CategoryScriptURI, Start: 37, End: 48, Match: "vb%73cript:", Severity: SeverityHigh},
+			},
+		},
+		{
+			name:  "String execution",
+			input: `setInterval('go()') Function('x')`,
+			want: []Finding{
+				{Category: CategoryStringExecution, Start: 0, End: 13, Match: "setInterval('", Severity: SeverityMedium},
+				{Category: CategoryStringExecution, Start: 20, End: 29, Match: "Function(", Severity: SeverityMedium},
+			},
+		},
+		{
+			name:  "Data URI",
+			input: `data: text/html,x`,
+			want:  []Finding{{Category: CategoryDataURI, Start: 0, End: 15, Match: "data: text/html", Severity: SeverityHigh}},
+		},
+		{
+			name:  "Findings inside a script block",
+			input: `<script>eval(x)</script>`,
+			want: []Finding{
+				{Category: CategoryScriptTag, Start: 0, End: 24, Match: "<script>eval(x)</script>", Severity: SeverityHigh},
+				{Category: CategoryEval, Start: 8, End: 13, Match: "eval(", Severity: SeverityMedium},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+
+			for _, f := range got {
+				if tt.input[f.Start:f.End] != f.Match {
+					t.Errorf("Detect() offsets %d:%d do not match %q", f.Start, f.End, f.Match)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectJSON(t *testing.T) {
+	got, err := json.Marshal(Detect("eval(1)"))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `[{"category":"eval_call","start":0,"end":5,"match":"eval(","severity":"medium"}]`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
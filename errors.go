@@ -0,0 +1,134 @@
+package sanitizer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotPointer is returned when SanitizeStruct is not given a pointer to a struct
+	ErrNotPointer = errors.New("struct needs to be a pointer")
+
+	// ErrEmptyStruct is returned when SanitizeStruct is given a struct without fields
+	ErrEmptyStruct = errors.New("struct is empty")
+
+	// ErrMaxDepth is returned when values are nested deeper than the configured maximum depth
+	ErrMaxDepth = errors.New("maximum depth exceeded")
+
+	// ErrUnsettable is returned for tagged fields that cannot be set, like unexported fields
+	ErrUnsettable = errors.New("cannot be set, it is unexported or not addressable")
+
+	// ErrKeyCollision is returned when two map keys are the same after sanitization
+	ErrKeyCollision = errors.New("map keys collide")
+
+	// ErrRejected is returned in reject mode for values the rules would change, the value is left untouched
+	ErrRejected = errors.New("value would be changed by sanitization")
+
+	// ErrHomograph is returned by RejectHomograph, and by url and domain with homograph=reject, for hosts mixing scripts
+	ErrHomograph = errors.New("domain mixes scripts")
+)
+
+// FieldError is a failure on a single field
+type FieldError struct {
+	Path string // field path, e.g. Address.ZipCode or Tags[3]
+	Rule string // rule that failed, empty when the field itself is invalid
+	Err  error  // cause of the failure
+}
+
+func (e *FieldError) Error() string {
+	// Values given to Value and hooks of the root struct fail without a path
+	subject := "value"
+	if e.Path != "" {
+		subject = "field " + e.Path
+	}
+
+	if e.Rule == "" {
+		return fmt.Sprintf("%s: %v", subject, e.Err)
+	}
+
+	return fmt.Sprintf("%s: rule %s: %v", subject, e.Rule, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects every field failure of a sanitization run, fields that failed keep their value
+type Errors struct {
+	Fields []*FieldError
+}
+
+func (e *Errors) Error() string {
+	if len(e.Fields) == 1 {
+		return e.Fields[0].Error()
+	}
+
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+
+	return fmt.Sprintf("%d fields failed: %s", len(e.Fields), strings.Join(messages, "; "))
+}
+
+// Add records a field failure, it is used by code generated with sanitizegen
+func (e *Errors) Add(path string, rule string, err error) {
+	e.Fields = append(e.Fields, &FieldError{Path: path, Rule: rule, Err: err})
+}
+
+// Merge records the failures of a nested value under prefix, it is used by code generated with sanitizegen
+func (e *Errors) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+
+	var nested *Errors
+	if !errors.As(err, &nested) {
+		e.Add(prefix, "", err)
+		return
+	}
+
+	for _, field := range nested.Fields {
+		e.Add(fieldPath(prefix, field.Path), field.Rule, field.Err)
+	}
+}
+
+// Err returns e when failures were recorded and nil otherwise
+func (e *Errors) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Unwrap lets errors.Is and errors.As look into every field failure
+func (e *Errors) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, field := range e.Fields {
+		errs[i] = field
+	}
+
+	return errs
+}
+
+// UnsettablePolicy defines what happens to tagged fields that cannot be set, like unexported fields
+type UnsettablePolicy int
+
+const (
+	// UnsettableError reports the field as a failure wrapping ErrUnsettable, this is the default
+	UnsettableError UnsettablePolicy = iota
+
+	// UnsettableSkip sanitizes every other field and reports the skipped ones with a *SkippedFieldsError
+	UnsettableSkip
+)
+
+// SkippedFieldsError lists the tagged fields skipped with UnsettableSkip, every other field was sanitized
+type SkippedFieldsError struct {
+	Paths []string
+}
+
+func (e *SkippedFieldsError) Error() string {
+	return fmt.Sprintf("skipped fields that cannot be set: %s", strings.Join(e.Paths, ", "))
+}
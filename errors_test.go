@@ -0,0 +1,253 @@
+package sanitizer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type embeddedAddress struct {
+	Street string `sanitize:"html"`
+}
+
+type unexportedPayload struct {
+	embeddedAddress
+	Name     string `sanitize:"html"`
+	secret   string `sanitize:"html"`
+	internal string
+	notes    []string          `sanitize:"html"`
+	meta     map[string]string `sanitize:"xss"`
+	nickname *string           `sanitize:"html"`
+	address  Address
+	any      any
+}
+
+func newUnexportedPayload() *unexportedPayload {
+	nickname := "<b>nick</b>"
+
+	return &unexportedPayload{
+		embeddedAddress: embeddedAddress{Street: "<b>street</b>"},
+		Name:            "<b>name</b>",
+		secret:          "<b>secret</b>",
+		internal:        "<b>internal</b>",
+		notes:           []string{"<b>note</b>"},
+		meta:            map[string]string{"key": "<b>value</b>"},
+		nickname:        &nickname,
+		address:         Address{City: "<b>city</b>"},
+		any:             "<b>any</b>",
+	}
+}
+
+func TestUnsettableError(t *testing.T) {
+	payload := newUnexportedPayload()
+
+	err := Struct("sanitize", payload)
+	if !errors.Is(err, ErrUnsettable) {
+		t.Fatalf("Struct() error = %v, want ErrUnsettable", err)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Path != "secret" {
+		t.Errorf("Struct() first field error = %v, want secret", fieldErr)
+	}
+
+	if payload.secret != "<b>secret</b>" {
+		t.Errorf("secret modified = %s", payload.secret)
+	}
+
+	// Every other field is still sanitized
+	if payload.Name != "name" || payload.Street != "street" {
+		t.Errorf("exported fields not sanitized = %q, %q", payload.Name, payload.Street)
+	}
+}
+
+func TestUnsettableSkip(t *testing.T) {
+	payload := newUnexportedPayload()
+
+	st := &StructSanitizer{unsettable: UnsettableSkip, verbose: true}
+	err := st.SanitizeStruct(payload)
+
+	var skipped *SkippedFieldsError
+	if !errors.As(err, &skipped) {
+		t.Fatalf("SanitizeStruct() error = %v, want *SkippedFieldsError", err)
+	}
+
+	want := []string{"secret", "notes", "meta", "nickname", "address.StreetAddress1", "address.StreetAddress2", "address.City", "address.ZipCode"}
+	if !reflect.DeepEqual(skipped.Paths, want) {
+		t.Errorf("SkippedFieldsError.Paths = %v, want %v", skipped.Paths, want)
+	}
+
+	// Exported and promoted fields are still sanitized
+	if payload.Name != "name" || payload.Street != "street" {
+		t.Errorf("exported fields not sanitized = %q, %q", payload.Name, payload.Street)
+	}
+
+	if payload.secret != "<b>secret</b>" || payload.notes[0] != "<b>note</b>" || *payload.nickname != "<b>nick</b>" {
+		t.Errorf("unexported fields modified = %+v", payload)
+	}
+}
+
+func TestSanitizeStructInvalidInput(t *testing.T) {
+	var nilPayload *Payload
+	text := "text"
+
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "Nil", value: nil},
+		{name: "Nil pointer", value: nilPayload},
+		{name: "Pointer to string", value: &text},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Struct("sanitize", tt.value); err == nil {
+				t.Errorf("Struct() expected error")
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	payload := &struct {
+		Website string `sanitize:"url"`
+		Name    string `sanitize:"html"`
+		Address struct {
+			ZipCode string `sanitize:"url"`
+		}
+		Tags   []string          `sanitize:"url"`
+		Labels map[string]string `sanitize:"keys=alpha"`
+		Broken string            `sanitize:"alpha(spaces"`
+	}{
+		Website: "http://\x00invalid",
+		Name:    "<b>name</b>",
+		Tags:    []string{"example.com", "example.com", "example.com", "http://\x00invalid"},
+		Labels:  map[string]string{"a1": "first", "a2": "second"},
+		Broken:  "broken",
+	}
+	payload.Address.ZipCode = "http://\x00invalid"
+
+	err := Struct("sanitize", payload)
+
+	var errs *Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Struct() error = %v, want *Errors", err)
+	}
+
+	tests := []struct {
+		path string
+		rule string
+		is   error
+	}{
+		{path: "Website", rule: "url"},
+		{path: "Address.ZipCode", rule: "url"},
+		{path: "Tags[3]", rule: "url"},
+		{path: "Labels", is: ErrKeyCollision},
+		{path: "Broken"},
+	}
+
+	if len(errs.Fields) != len(tests) {
+		t.Fatalf("Errors.Fields = %v, want %d entries", errs.Fields, len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			field := errs.Fields[i]
+			if field.Path != tt.path || field.Rule != tt.rule || field.Err == nil {
+				t.Errorf("Errors.Fields[%d] = %+v, want path %s rule %s", i, field, tt.path, tt.rule)
+			}
+			if tt.is != nil && !errors.Is(field, tt.is) {
+				t.Errorf("Errors.Fields[%d] = %v, want %v", i, field, tt.is)
+			}
+		})
+	}
+
+	if !errors.Is(err, ErrKeyCollision) {
+		t.Errorf("errors.Is(err, ErrKeyCollision) = false")
+	}
+
+	// Valid fields are sanitized, failed fields keep their value
+	if payload.Name != "name" || payload.Website != "http://\x00invalid" || payload.Tags[0] != "https://example.com" {
+		t.Errorf("Struct() payload = %+v", payload)
+	}
+
+	if len(payload.Labels) != 2 || payload.Labels["a1"] != "first" {
+		t.Errorf("Labels modified on collision = %v", payload.Labels)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	single := &Errors{Fields: []*FieldError{{Path: "Website", Rule: "url", Err: errors.New("invalid URL")}}}
+	if got := single.Error(); got != "field Website: rule url: invalid URL" {
+		t.Errorf("Errors.Error() = %s", got)
+	}
+
+	many := &Errors{Fields: []*FieldError{
+		{Path: "Website", Rule: "url", Err: errors.New("invalid URL")},
+		{Path: "secret", Err: ErrUnsettable},
+	}}
+	want := "2 fields failed: field Website: rule url: invalid URL; field secret: cannot be set, it is unexported or not addressable"
+	if got := many.Error(); got != want {
+		t.Errorf("Errors.Error() = %s, want %s", got, want)
+	}
+	// Values given to Value fail at the root, without a path
+	_, err := New(WithStrict(true)).Value("text", "nope")
+	if err == nil || err.Error() != "value: rule nope: unknown sanitize rule" {
+		t.Errorf("Value() error = %v", err)
+	}
+
+	_, err = Value("http://\x00invalid", "url")
+	if err == nil || !strings.HasPrefix(err.Error(), "value: rule url: invalid URL") {
+		t.Errorf("Value() error = %v", err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	text := "text"
+
+	if err := Struct("sanitize", Payload{}); !errors.Is(err, ErrNotPointer) {
+		t.Errorf("Struct() non-pointer error = %v", err)
+	}
+
+	if err := Struct("sanitize", &text); !errors.Is(err, ErrNotPointer) {
+		t.Errorf("Struct() pointer to string error = %v", err)
+	}
+
+	if err := Struct("sanitize", &EmptyStruct{}); !errors.Is(err, ErrEmptyStruct) {
+		t.Errorf("Struct() empty struct error = %v", err)
+	}
+}
+
+func TestErrorsMerge(t *testing.T) {
+	invalid := errors.New("invalid URL")
+
+	var nested Errors
+	if nested.Err() != nil {
+		t.Fatalf("Errors.Err() without failures = %v, want nil", nested.Err())
+	}
+	nested.Add("Website", "url", invalid)
+
+	// Failures of the nested struct itself, e.g. from its hooks, have an empty path
+	var hook Errors
+	hook.Add("", "", ErrRejected)
+
+	var errs Errors
+	errs.Merge("Address", nil)
+	errs.Merge("Address", nested.Err())
+	errs.Merge("Items[0]", ErrMaxDepth)
+	errs.Merge("Name", hook.Err())
+
+	want := []*FieldError{
+		{Path: "Address.Website", Rule: "url", Err: invalid},
+		{Path: "Items[0]", Err: ErrMaxDepth},
+		{Path: "Name", Err: ErrRejected},
+	}
+	if !reflect.DeepEqual(errs.Fields, want) {
+		t.Errorf("Errors.Merge() fields = %+v, want %+v", errs.Fields, want)
+	}
+
+	if err := errs.Err(); !errors.Is(err, invalid) || !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("Errors.Err() = %v", err)
+	}
+}
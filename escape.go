@@ -0,0 +1,87 @@
+package sanitizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// jsonScriptEscaper escapes the characters that end a script element or a JavaScript string in JSON text
+var jsonScriptEscaper = strings.NewReplacer(
+	"<", `\u003c`,
+	">", `\u003e`,
+	"&", `\u0026`,
+	"\u2028", `\u2028`,
+	"\u2029", `\u2029`,
+)
+
+// EscapeHTMLAttr escapes a value written inside an HTML attribute, quoted or not. Every character below 256
+// other than letters, digits and , . - _ is written as &#xHH; as recommended by OWASP.
+func EscapeHTMLAttr(input string) string {
+	return escapeRunes(input, ",.-_", func(b *strings.Builder, r rune) {
+		fmt.Fprintf(b, "&#x%02X;", r)
+	})
+}
+
+// EscapeJSString escapes a value written inside a quoted JavaScript string. Every character below 256 other than
+// letters, digits and , . _ is written as \xHH, the line and paragraph separators as \u2028 and \u2029.
+func EscapeJSString(input string) string {
+	return escapeRunes(input, ",._", func(b *strings.Builder, r rune) {
+		if r > 0xff {
+			fmt.Fprintf(b, `\u%04X`, r)
+			return
+		}
+		fmt.Fprintf(b, `\x%02X`, r)
+	})
+}
+
+// EscapeCSSString escapes a value written inside a quoted CSS string or property value. Every character below 256
+// other than letters and digits is written as \HH followed by a space, which ends the escape.
+func EscapeCSSString(input string) string {
+	return escapeRunes(input, "", func(b *strings.Builder, r rune) {
+		fmt.Fprintf(b, `\%X `, r)
+	})
+}
+
+// EscapeURLComponent percent encodes a value written as a URL path segment, a query key or a query value,
+// only the unreserved characters of RFC 3986 are kept
+func EscapeURLComponent(input string) string {
+	var b strings.Builder
+	b.Grow(len(input))
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if isASCIILetter(c) || (c >= '0' && c <= '9') || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+// EscapeJSONForScript escapes JSON text written inside a script element, <, > and & are written as \u003c,
+// \u003e and \u0026 so </script> and <!-- cannot appear and the JSON keeps its value
+func EscapeJSONForScript(input string) string {
+	return jsonScriptEscaper.Replace(input)
+}
+
+// escapeRunes writes letters, digits, the immune characters and runes from 256 up as they are,
+// the other runes are written by escape. The line and paragraph separators are always escaped.
+func escapeRunes(input string, immune string, escape func(b *strings.Builder, r rune)) string {
+	var b strings.Builder
+	b.Grow(len(input))
+
+	for _, r := range input {
+		switch {
+		case r < 0x80 && (isASCIILetter(byte(r)) || (r >= '0' && r <= '9') || strings.ContainsRune(immune, r)):
+			b.WriteRune(r)
+		case r > 0xff && r != '\u2028' && r != '\u2029':
+			b.WriteRune(r)
+		default:
+			escape(&b, r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package sanitizer
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	lineSeparator      = string(rune(0x2028))
+	paragraphSeparator = string(rune(0x2029))
+)
+
+// breakouts try to leave the context they are written in
+var breakouts = []string{
+	`"><script>alert(1)</script>`,
+	`' onmouseover='alert(1)`,
+	`x onerror=alert(1)`,
+	"</script><script>alert(1)</script>",
+	`\"; alert(1); //`,
+	"`${alert(1)}`",
+	"a\nb\r" + lineSeparator + "c" + paragraphSeparator,
+	`</style><style>body{}`,
+	`red; background: url(javascript:alert(1))`,
+	`\"); } * { color: red`,
+	`a b&c=d/e?f#g%20`,
+	`<!--`,
+	`é ü 日本`,
+}
+
+func TestEscapeHTMLAttr(t *testing.T) {
+	if got := EscapeHTMLAttr(`a-b_c.d, "x" <y>`); got != `a-b_c.d,&#x20;&#x22;x&#x22;&#x20;&#x3C;y&#x3E;` {
+		t.Errorf("EscapeHTMLAttr() = %s", got)
+	}
+
+	for _, input := range breakouts {
+		got := EscapeHTMLAttr(input)
+		if strings.ContainsAny(got, "\"'`<>= \t\n\r") {
+			t.Errorf("EscapeHTMLAttr(%q) = %q can leave the attribute", input, got)
+		}
+		if html.UnescapeString(got) != input {
+			t.Errorf("EscapeHTMLAttr(%q) = %q does not decode back", input, got)
+		}
+	}
+}
+
+func TestEscapeJSString(t *testing.T) {
+	if got := EscapeJSString(`it's </script> ` + lineSeparator); got != `it\x27s\x20\x3C\x2Fscript\x3E\x20\u2028` {
+		t.Errorf("EscapeJSString() = %s", got)
+	}
+
+	for _, input := range breakouts {
+		got := EscapeJSString(input)
+		if strings.ContainsAny(got, "\"'`<>/\n\r"+lineSeparator+paragraphSeparator) {
+			t.Errorf("EscapeJSString(%q) = %q can leave the string", input, got)
+		}
+
+		// Go reads \xHH as a byte, only ASCII decodes back to the same string
+		if unquoted, err := strconv.Unquote(`"` + got + `"`); !isASCII(input) {
+			continue
+		} else if err != nil || unquoted != input {
+			t.Errorf("EscapeJSString(%q) = %q does not decode back", input, got)
+		}
+	}
+}
+
+func TestEscapeCSSString(t *testing.T) {
+	if got := EscapeCSSString(`a"b;`); got != `a\22 b\3B ` {
+		t.Errorf("EscapeCSSString() = %s", got)
+	}
+
+	cssEscape := regexp.MustCompile(`\\[0-9A-F]+ `)
+	for _, input := range breakouts {
+		got := EscapeCSSString(input)
+		if strings.ContainsAny(cssEscape.ReplaceAllString(got, ""), "\"'<>(){};:/\\ \n\r") {
+			t.Errorf("EscapeCSSString(%q) = %q can leave the value", input, got)
+		}
+
+		decoded := cssEscape.ReplaceAllStringFunc(got, func(escape string) string {
+			r, _ := strconv.ParseUint(strings.TrimSpace(escape[1:]), 16, 32)
+			return string(rune(r))
+		})
+		if decoded != input {
+			t.Errorf("EscapeCSSString(%q) = %q does not decode back", input, got)
+		}
+	}
+}
+
+func TestEscapeURLComponent(t *testing.T) {
+	if got := EscapeURLComponent("a b&c=d/é~"); got != "a%20b%26c%3Dd%2F%C3%A9~" {
+		t.Errorf("EscapeURLComponent() = %s", got)
+	}
+
+	unreserved := regexp.MustCompile(`^[A-Za-z0-9._~%-]*$`)
+	for _, input := range breakouts {
+		got := EscapeURLComponent(input)
+		if !unreserved.MatchString(got) {
+			t.Errorf("EscapeURLComponent(%q) = %q can leave the component", input, got)
+		}
+
+		for _, unescape := range []func(string) (string, error){url.PathUnescape, url.QueryUnescape} {
+			if decoded, err := unescape(got); err != nil || decoded != input {
+				t.Errorf("EscapeURLComponent(%q) = %q does not decode back", input, got)
+			}
+		}
+	}
+}
+
+func TestEscapeJSONForScript(t *testing.T) {
+	for _, input := range breakouts {
+		value := map[string]string{"comment": input}
+
+		var encoded strings.Builder
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+
+		got := EscapeJSONForScript(encoded.String())
+		if strings.ContainsAny(got, "<>&"+lineSeparator+paragraphSeparator) {
+			t.Errorf("EscapeJSONForScript(%q) = %q can leave the script", encoded.String(), got)
+		}
+
+		var decoded map[string]string
+		if err := json.Unmarshal([]byte(got), &decoded); err != nil || decoded["comment"] != input {
+			t.Errorf("EscapeJSONForScript(%q) = %q does not decode back", encoded.String(), got)
+		}
+	}
+}
+
+func TestStructEscape(t *testing.T) {
+	payload := &struct {
+		Attr string `sanitize:"html_attr_escape"`
+		JS   string `sanitize:"js_escape"`
+		CSS  string `sanitize:"css_escape"`
+		URL  string `sanitize:"url_escape"`
+		JSON string `sanitize:"json_script_escape"`
+	}{Attr: `"x"`, JS: `'x'`, CSS: `;`, URL: `a b`, JSON: `{"a":"</script>"}`}
+
+	if err := New().SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if payload.Attr != "&#x22;x&#x22;" || payload.JS != `\x27x\x27` || payload.CSS != `\3B ` || payload.URL != "a%20b" || payload.JSON != `{"a":"\u003c/script\u003e"}` {
+		t.Errorf("SanitizeStruct() = %+v", payload)
+	}
+}
@@ -0,0 +1,152 @@
+package sanitizer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/JoshuaJimenezR/sanitizer"
+	"github.com/JoshuaJimenezR/sanitizer/examples"
+)
+
+func TestStructRuleChains(t *testing.T) {
+	payload := &examples.Payload{
+		FirstName: `First <script>$("#something").hide()</script>Name 123`,
+		LastName:  `Last <embed width="50" class="something"></embed>Name`,
+		Website:   "https://www.domain.com",
+		Username:  "/This/Works/?that=123&this#page10%",
+		Address: examples.Address{
+			StreetAddress1: `<p>Street Address 1</p>`,
+			StreetAddress2: `<b>Street Address 2</b>`,
+			City:           `<CustomTags>City</CustomTags>`,
+			ZipCode:        `SW1W 0NY<b>!</b>`,
+		},
+	}
+
+	if err := sanitizer.Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "xss, alpha", got: payload.FirstName, want: "First Name "},
+		{name: "alpha, scripts", got: payload.LastName, want: "Last embed width classsomethingembedName"},
+		{name: "url", got: payload.Website, want: "https://domain.com"},
+		{name: "uri", got: payload.Username, want: "/This/Works/?that=123&this#page10%"},
+		{name: "html", got: payload.Address.StreetAddress1, want: "Street Address 1"},
+		{name: "html_escape", got: payload.Address.StreetAddress2, want: "&lt;b&gt;Street Address 2&lt;/b&gt;"},
+		{name: "xml", got: payload.Address.City, want: "City"},
+		{name: "alphanumeric, xss", got: payload.Address.ZipCode, want: "SW1W 0NYbb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s sanitize error = %q, want %q", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func newExamplePayload(website string) *examples.Payload {
+	return &examples.Payload{
+		FirstName: `First <script>$("#something").hide()</script>Name 123`,
+		LastName:  `Last <embed width="50" class="something"></embed>Name`,
+		Age:       30,
+		Website:   website,
+		Username:  "/This/Works/?that=123&this#page10%",
+		Address: examples.Address{
+			StreetAddress1: `<!DOCTYPE html><html lang="en"><head><title>Street Address 1</title></head><body><script src="index.js"></script></body></html>`,
+			StreetAddress2: `<b>Street Address 2</b>`,
+			City:           `<CustomTags>City</CustomTags>`,
+			ZipCode:        `SW1W 0NY<script>$("#something").hide()</script>`,
+		},
+	}
+}
+
+// TestGeneratedSanitize checks the code generated by sanitizegen against StructSanitizer
+func TestGeneratedSanitize(t *testing.T) {
+	for _, website := range []string{"www.domain.com", "http://\x00invalid"} {
+		t.Run(website, func(t *testing.T) {
+			generated := newExamplePayload(website)
+			reflected := newExamplePayload(website)
+
+			generatedErr := generated.Sanitize()
+			reflectedErr := sanitizer.Struct("sanitize", reflected)
+
+			if !reflect.DeepEqual(generated, reflected) {
+				t.Errorf("Sanitize() = %+v, StructSanitizer = %+v", generated, reflected)
+			}
+
+			if !reflect.DeepEqual(generatedErr, reflectedErr) {
+				t.Errorf("Sanitize() error = %v, StructSanitizer error = %v", generatedErr, reflectedErr)
+			}
+		})
+	}
+}
+
+func newExampleProfile(first string, amount examples.Amount) *examples.Profile {
+	return &examples.Profile{
+		Name:    examples.Name{First: "  " + first + "  ", Last: " <b>doe</b> "},
+		Tags:    []examples.Tag{"Go 1", "<i>News</i>"},
+		Amounts: map[string]examples.Amount{"total": amount, "tax": "$2"},
+		Manager: &examples.Name{First: first, Last: "smith"},
+	}
+}
+
+// TestGeneratedHooks checks that the generated code calls BeforeSanitize, AfterSanitize and hand written
+// Sanitize methods like StructSanitizer
+func TestGeneratedHooks(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  string
+		amount examples.Amount
+	}{
+		{name: "Hooks", first: "<b>x</b>", amount: "$10"},
+		{name: "BeforeSanitize errors", first: " ", amount: "-$10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := newExampleProfile(tt.first, tt.amount)
+			reflected := newExampleProfile(tt.first, tt.amount)
+
+			generatedErr := generated.Sanitize()
+			reflectedErr := sanitizer.Struct("sanitize", reflected)
+
+			if !reflect.DeepEqual(generated, reflected) {
+				t.Errorf("Sanitize() = %+v, StructSanitizer = %+v", generated, reflected)
+			}
+
+			if !reflect.DeepEqual(generatedErr, reflectedErr) {
+				t.Errorf("Sanitize() error = %v, StructSanitizer error = %v", generatedErr, reflectedErr)
+			}
+
+			// The struct given to SanitizeStruct runs its BeforeSanitize and AfterSanitize hooks too
+			generatedRoot, reflectedRoot := generated.Name, reflected.Name
+			generatedRoot.First, reflectedRoot.First = tt.first, tt.first
+
+			generatedErr = generatedRoot.Sanitize()
+			reflectedErr = sanitizer.Struct("sanitize", &reflectedRoot)
+
+			if generatedRoot != reflectedRoot || !reflect.DeepEqual(generatedErr, reflectedErr) {
+				t.Errorf("Name.Sanitize() = %+v, %v, StructSanitizer = %+v, %v", generatedRoot, generatedErr, reflectedRoot, reflectedErr)
+			}
+		})
+	}
+
+	generated := newExampleProfile("<b>x</b>", "$10")
+	if err := generated.Sanitize(); err != nil {
+		t.Fatalf("Sanitize() error = %v", err)
+	}
+
+	want := &examples.Profile{
+		Name:    examples.Name{First: "x", Last: "DOE"},
+		Tags:    []examples.Tag{"go ", "inewsi"},
+		Amounts: map[string]examples.Amount{"total": "10", "tax": "2"},
+		Manager: &examples.Name{First: "x", Last: "SMITH"},
+	}
+	if !reflect.DeepEqual(generated, want) {
+		t.Errorf("Sanitize() = %+v, want %+v", generated, want)
+	}
+}
@@ -0,0 +1,63 @@
+package examples
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrEmptyName is returned by Name.BeforeSanitize when the first name is blank
+var ErrEmptyName = errors.New("first name is empty")
+
+// ErrNegativeAmount is returned by Amount.BeforeSanitize for negative amounts
+var ErrNegativeAmount = errors.New("amount is negative")
+
+// Name is trimmed before its tags are applied and its last name is upper cased after
+type Name struct {
+	First string `json:"first" sanitize:"html"`
+	Last  string `json:"last" sanitize:"html"`
+}
+
+func (n *Name) BeforeSanitize() error {
+	n.First, n.Last = strings.TrimSpace(n.First), strings.TrimSpace(n.Last)
+	if n.First == "" {
+		return ErrEmptyName
+	}
+
+	return nil
+}
+
+func (n *Name) AfterSanitize() error {
+	n.Last = strings.ToUpper(n.Last)
+	return nil
+}
+
+// Tag is lower cased after its rules are applied
+type Tag string
+
+func (t *Tag) AfterSanitize() error {
+	*t = Tag(strings.ToLower(string(*t)))
+	return nil
+}
+
+// Amount cleans itself, negative amounts are refused before
+type Amount string
+
+func (a *Amount) BeforeSanitize() error {
+	if strings.HasPrefix(string(*a), "-") {
+		return ErrNegativeAmount
+	}
+
+	return nil
+}
+
+func (a *Amount) Sanitize() error {
+	*a = Amount(strings.TrimPrefix(string(*a), "$"))
+	return nil
+}
+
+type Profile struct {
+	Name    Name              `json:"name"`
+	Tags    []Tag             `json:"tags" sanitize:"alpha"`
+	Amounts map[string]Amount `json:"amounts"`
+	Manager *Name             `json:"manager"`
+}
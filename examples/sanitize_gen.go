@@ -0,0 +1,78 @@
+// Code generated by sanitizegen. DO NOT EDIT.
+
+package examples
+
+import (
+	"github.com/JoshuaJimenezR/sanitizer"
+	"strconv"
+)
+
+// Sanitize cleans the fields of Name based on their sanitize tags
+func (p *Name) Sanitize() error {
+	var errs sanitizer.Errors
+
+	if err := p.BeforeSanitize(); err != nil {
+		errs.Merge("", err)
+		return errs.Err()
+	}
+
+	p.First = sanitizer.HTML(p.First)
+	p.Last = sanitizer.HTML(p.Last)
+
+	errs.Merge("", p.AfterSanitize())
+
+	return errs.Err()
+}
+
+// Sanitize cleans the fields of Profile based on their sanitize tags
+func (p *Profile) Sanitize() error {
+	var errs sanitizer.Errors
+
+	errs.Merge("Name", p.Name.Sanitize())
+	for i := range p.Tags {
+		p.Tags[i] = Tag(sanitizer.Alpha(string(p.Tags[i]), true))
+		errs.Merge("Tags["+strconv.Itoa(i)+"]", p.Tags[i].AfterSanitize())
+	}
+	for k, v := range p.Amounts {
+		if err := v.BeforeSanitize(); err != nil {
+			errs.Merge("Amounts["+k+"]", err)
+		} else {
+			errs.Merge("Amounts["+k+"]", v.Sanitize())
+		}
+		p.Amounts[k] = v
+	}
+	if p.Manager != nil {
+		errs.Merge("Manager", p.Manager.Sanitize())
+	}
+
+	return errs.Err()
+}
+
+// Sanitize cleans the fields of Address based on their sanitize tags
+func (p *Address) Sanitize() error {
+	var errs sanitizer.Errors
+
+	p.StreetAddress1 = sanitizer.HTML(p.StreetAddress1)
+	p.StreetAddress2 = sanitizer.HtmlEscape(p.StreetAddress2)
+	p.City = sanitizer.XML(p.City)
+	p.ZipCode = sanitizer.XSS(sanitizer.AlphaNumeric(p.ZipCode, true))
+
+	return errs.Err()
+}
+
+// Sanitize cleans the fields of Payload based on their sanitize tags
+func (p *Payload) Sanitize() error {
+	var errs sanitizer.Errors
+
+	p.FirstName = sanitizer.Alpha(sanitizer.XSS(p.FirstName), true)
+	p.LastName = sanitizer.Scripts(sanitizer.Alpha(p.LastName, true))
+	if sanitized, err := sanitizer.URL(p.Website, true); err != nil {
+		errs.Add("Website", "url", err)
+	} else {
+		p.Website = sanitized
+	}
+	p.Username = sanitizer.URI(p.Username)
+	errs.Merge("Address", p.Address.Sanitize())
+
+	return errs.Err()
+}
@@ -1,5 +1,7 @@
 package examples
 
+//go:generate go run ../cmd/sanitizegen
+
 type Address struct {
 	StreetAddress1 string `json:"street_address_1" sanitize:"html"`
 	StreetAddress2 string `json:"street_address_2" sanitize:"html_escape"`
@@ -0,0 +1,140 @@
+package sanitizer
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Sanitizer is implemented by types that clean themselves, the method is called instead of walking the value
+type Sanitizer interface {
+	Sanitize() error
+}
+
+// SanitizerWith is like Sanitizer but receives the running StructSanitizer, so the type can reuse its rules and options
+type SanitizerWith interface {
+	SanitizeWith(st *StructSanitizer) error
+}
+
+// BeforeSanitizer is called before a value is sanitized, an error leaves the value untouched
+type BeforeSanitizer interface {
+	BeforeSanitize() error
+}
+
+// AfterSanitizer is called after a value is sanitized
+type AfterSanitizer interface {
+	AfterSanitize() error
+}
+
+// hookSet holds the hook interfaces implemented by a type
+type hookSet uint8
+
+const (
+	sanitizeHook hookSet = 1 << iota
+	sanitizeWithHook
+	beforeHook
+	afterHook
+
+	// selfHooks replace the walk over the value
+	selfHooks = sanitizeHook | sanitizeWithHook
+)
+
+var (
+	sanitizerType       = reflect.TypeOf((*Sanitizer)(nil)).Elem()
+	sanitizerWithType   = reflect.TypeOf((*SanitizerWith)(nil)).Elem()
+	beforeSanitizerType = reflect.TypeOf((*BeforeSanitizer)(nil)).Elem()
+	afterSanitizerType  = reflect.TypeOf((*AfterSanitizer)(nil)).Elem()
+
+	// hookSets caches the hooks of every type, they do not depend on the sanitizer options
+	hookSets sync.Map // reflect.Type to hookSet
+)
+
+// typeHooks returns the hooks implemented by t or *t, pointers and interfaces have none
+// because the walker calls the hooks on the value they point to
+func typeHooks(t reflect.Type) hookSet {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return 0
+	}
+
+	if hooks, ok := hookSets.Load(t); ok {
+		return hooks.(hookSet)
+	}
+
+	var hooks hookSet
+	pt := reflect.PointerTo(t)
+
+	for _, h := range []struct {
+		iface reflect.Type
+		hook  hookSet
+	}{
+		{sanitizerType, sanitizeHook},
+		{sanitizerWithType, sanitizeWithHook},
+		{beforeSanitizerType, beforeHook},
+		{afterSanitizerType, afterHook},
+	} {
+		if pt.Implements(h.iface) {
+			hooks |= h.hook
+		}
+	}
+
+	hookSets.Store(t, hooks)
+
+	return hooks
+}
+
+// readHooked runs the hooks of an addressable value around its sanitization,
+// Sanitize and SanitizeWith replace the walk when they are part of hooks
+func (w *walker) readHooked(v reflect.Value, path string, hooks hookSet, walk func()) {
+	target := v.Addr().Interface()
+
+	if hooks&beforeHook != 0 {
+		if err := w.callHook(v, path, "BeforeSanitize", target.(BeforeSanitizer).BeforeSanitize); err != nil {
+			w.merge(path, err)
+			return
+		}
+	}
+
+	switch {
+	case hooks&sanitizeWithHook != 0:
+		w.merge(path, w.callHook(v, path, "SanitizeWith", func() error {
+			return target.(SanitizerWith).SanitizeWith(w.StructSanitizer)
+		}))
+
+	case hooks&sanitizeHook != 0:
+		w.merge(path, w.callHook(v, path, "Sanitize", target.(Sanitizer).Sanitize))
+
+	default:
+		walk()
+	}
+
+	if hooks&afterHook != 0 {
+		w.merge(path, w.callHook(v, path, "AfterSanitize", target.(AfterSanitizer).AfterSanitize))
+	}
+}
+
+// callHook calls a hook of v, when the run builds a report the strings it changed are recorded under its name
+func (w *walker) callHook(v reflect.Value, path string, name string, hook func() error) error {
+	if w.report == nil {
+		return hook()
+	}
+
+	before := deepCopy(v)
+	err := hook()
+	w.reportDiff(path, name, before, v, map[uintptr]bool{})
+
+	return err
+}
+
+// merge records the error returned by a hook, field failures and skipped fields are moved under path
+func (w *walker) merge(path string, err error) {
+	if skipped, ok := err.(*SkippedFieldsError); ok {
+		for _, p := range skipped.Paths {
+			w.skipped = append(w.skipped, fieldPath(path, p))
+		}
+		return
+	}
+
+	var errs Errors
+	errs.Merge(path, err)
+
+	w.errs = append(w.errs, errs.Fields...)
+}
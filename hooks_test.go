@@ -0,0 +1,173 @@
+package sanitizer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	errLocked      = errors.New("value is locked")
+	errInvalidTest = errors.New("invalid URL")
+)
+
+// money keeps the digits and the decimal point
+type money string
+
+func (m *money) Sanitize() error {
+	*m = money(strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' {
+			return r
+		}
+		return -1
+	}, string(*m)))
+
+	return nil
+}
+
+// hookedAddress upper cases the country and then applies its tags with the running sanitizer
+type hookedAddress struct {
+	Street  string `sanitize:"html"`
+	Country string
+}
+
+func (a *hookedAddress) SanitizeWith(st *StructSanitizer) error {
+	a.Country = strings.ToUpper(a.Country)
+
+	return st.SanitizeStruct(a)
+}
+
+// audited records the hooks called on it
+type audited struct {
+	Name   string `sanitize:"html"`
+	Events []string
+}
+
+func (a *audited) BeforeSanitize() error {
+	a.Events = append(a.Events, "before "+a.Name)
+	return nil
+}
+
+func (a *audited) AfterSanitize() error {
+	a.Events = append(a.Events, "after "+a.Name)
+	return nil
+}
+
+// locked refuses to be sanitized
+type locked struct {
+	Name string `sanitize:"html"`
+}
+
+func (l *locked) BeforeSanitize() error {
+	return errLocked
+}
+
+// invalidWebsite reports a field failure from its own Sanitize method
+type invalidWebsite struct {
+	Website string
+}
+
+func (i *invalidWebsite) Sanitize() error {
+	return &Errors{Fields: []*FieldError{{Path: "Website", Rule: "url", Err: errInvalidTest}}}
+}
+
+type hookedOrder struct {
+	Total   money
+	Prices  []money
+	ByItem  map[string]money
+	Address hookedAddress
+	Audit   *audited
+	Locked  locked
+	Invalid invalidWebsite
+	Note    string `sanitize:"html"`
+	Events  []string
+}
+
+func (o *hookedOrder) BeforeSanitize() error {
+	o.Events = append(o.Events, "before "+o.Note)
+	return nil
+}
+
+func (o *hookedOrder) AfterSanitize() error {
+	o.Events = append(o.Events, "after "+o.Note)
+	return nil
+}
+
+// Sanitize is not called on the value given to SanitizeStruct
+func (o *hookedOrder) Sanitize() error {
+	o.Note = "sanitize called"
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	order := &hookedOrder{
+		Total:   "$ 1,200.50",
+		Prices:  []money{"USD 10", "<b>20</b>"},
+		ByItem:  map[string]money{"book": "12 EUR"},
+		Address: hookedAddress{Street: "<b>Main</b>", Country: "uk"},
+		Audit:   &audited{Name: "<b>audit</b>"},
+		Locked:  locked{Name: "<b>locked</b>"},
+		Invalid: invalidWebsite{Website: "website"},
+		Note:    "<b>note</b>",
+	}
+
+	err := New().SanitizeStruct(order)
+
+	want := &hookedOrder{
+		Total:   "1200.50",
+		Prices:  []money{"10", "20"},
+		ByItem:  map[string]money{"book": "12"},
+		Address: hookedAddress{Street: "Main", Country: "UK"},
+		Audit:   &audited{Name: "audit", Events: []string{"before <b>audit</b>", "after audit"}},
+		Locked:  locked{Name: "<b>locked</b>"},
+		Invalid: invalidWebsite{Website: "website"},
+		Note:    "note",
+		Events:  []string{"before <b>note</b>", "after note"},
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("SanitizeStruct() = %+v, want %+v", order, want)
+	}
+
+	wantErr := &Errors{Fields: []*FieldError{
+		{Path: "Locked", Err: errLocked},
+		{Path: "Invalid.Website", Rule: "url", Err: errInvalidTest},
+	}}
+	if !reflect.DeepEqual(err, wantErr) {
+		t.Errorf("SanitizeStruct() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestHooksValue(t *testing.T) {
+	got, err := Value(map[string]any{"total": money("€ 5"), "prices": []money{"1 $"}})
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	want := map[string]any{"total": money("5"), "prices": []money{"1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Value() = %v, want %v", got, want)
+	}
+}
+
+func TestTypeHooks(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want hookSet
+	}{
+		{name: "Sanitize", v: money(""), want: sanitizeHook},
+		{name: "SanitizeWith", v: hookedAddress{}, want: sanitizeWithHook},
+		{name: "Before and after", v: audited{}, want: beforeHook | afterHook},
+		{name: "Every hook", v: hookedOrder{}, want: sanitizeHook | beforeHook | afterHook},
+		{name: "Pointers call the hooks of their value", v: &audited{}, want: 0},
+		{name: "No hooks", v: Address{}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typeHooks(reflect.TypeOf(tt.v)); got != tt.want {
+				t.Errorf("typeHooks() = %b, want %b", got, tt.want)
+			}
+		})
+	}
+}
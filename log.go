@@ -0,0 +1,53 @@
+package sanitizer
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// logger returns the logger for sanitization records, verbose mode without a logger writes debug records to stdout
+func (st *StructSanitizer) logger() *slog.Logger {
+	if st.log != nil {
+		return st.log
+	}
+
+	if st.verbose {
+		return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	return nil
+}
+
+// logRule records a single rule applied to a field
+func (st *StructSanitizer) logRule(logger *slog.Logger, path string, r compiledRule, before string, after string) {
+	if logger == nil || !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("path", path),
+		slog.String("rule", r.String()),
+		slog.Bool("changed", before != after),
+		slog.Int("before_len", len(before)),
+		slog.Int("after_len", len(after)),
+	}
+
+	if !st.redact {
+		attrs = append(attrs, slog.String("before", before), slog.String("after", after))
+	}
+
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "sanitized field", attrs...)
+}
+
+// logSkipped records a tagged field skipped because it cannot be set
+func (st *StructSanitizer) logSkipped(logger *slog.Logger, path string) {
+	if logger == nil {
+		return
+	}
+
+	logger.LogAttrs(context.Background(), slog.LevelWarn, "skipped field",
+		slog.String("path", path),
+		slog.String("reason", "field cannot be set"),
+	)
+}
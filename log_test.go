@@ -0,0 +1,139 @@
+package sanitizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func decodeRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	payload := &struct {
+		Name    string `sanitize:"html, alpha(spaces=false)"`
+		Address Address
+		Labels  map[string]string `sanitize:"keys=alpha"`
+		secret  string            `sanitize:"html"`
+	}{
+		Name:    "<b>John Doe</b>",
+		Address: Address{City: "City"},
+		Labels:  map[string]string{"key1": "value"},
+	}
+
+	st := New(WithLogger(logger), WithUnsettable(UnsettableSkip))
+	_ = st.SanitizeStruct(payload)
+
+	records := decodeRecords(t, &buf)
+	if len(records) == 0 {
+		t.Fatalf("no records logged")
+	}
+
+	first := records[0]
+	want := map[string]any{
+		"level":      "DEBUG",
+		"msg":        "sanitized field",
+		"path":       "Name",
+		"rule":       "html",
+		"changed":    true,
+		"before_len": float64(15),
+		"after_len":  float64(8),
+		"before":     "<b>John Doe</b>",
+		"after":      "John Doe",
+	}
+	for key, value := range want {
+		if first[key] != value {
+			t.Errorf("record[%s] = %v, want %v", key, first[key], value)
+		}
+	}
+
+	found := map[string]bool{}
+	for _, record := range records {
+		found[fmt.Sprint(record["path"], " ", record["rule"], " ", record["level"])] = true
+	}
+
+	for _, key := range []string{
+		"Name alpha(spaces=false) DEBUG",
+		"Address.City xml DEBUG",
+		"Labels[key1] keys=alpha DEBUG",
+	} {
+		if !found[key] {
+			t.Errorf("missing record %q in %v", key, found)
+		}
+	}
+
+	var skipped bool
+	for _, record := range records {
+		if record["msg"] == "skipped field" && record["path"] == "secret" && record["level"] == "WARN" {
+			skipped = true
+		}
+	}
+	if !skipped {
+		t.Errorf("missing skipped field record")
+	}
+}
+
+func TestWithRedact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	payload := &struct {
+		Password string `sanitize:"html"`
+	}{
+		Password: "<b>secret</b>",
+	}
+
+	if err := New(WithLogger(logger), WithRedact(true)).SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("redacted record contains the value: %s", buf.String())
+	}
+
+	records := decodeRecords(t, &buf)
+	if len(records) != 1 || records[0]["before_len"] != float64(13) || records[0]["after_len"] != float64(6) {
+		t.Errorf("redacted records = %v", records)
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	payload := &struct {
+		Name string `sanitize:"html"`
+	}{
+		Name: "<b>name</b>",
+	}
+
+	if err := New(WithLogger(logger)).SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("debug records logged at info level: %s", buf.String())
+	}
+}
@@ -0,0 +1,87 @@
+package sanitizer
+
+import "log/slog"
+
+// defaultMaxDepth limits how deep New sanitizers walk nested values, it stops self referencing values
+const defaultMaxDepth = 64
+
+// Option configures a StructSanitizer created with New
+type Option func(*StructSanitizer)
+
+// New creates a reusable StructSanitizer, by default it reads the "sanitize" tag
+func New(opts ...Option) *StructSanitizer {
+	st := &StructSanitizer{
+		tagName:  defaultTagName,
+		maxDepth: defaultMaxDepth,
+	}
+
+	for _, opt := range opts {
+		opt(st)
+	}
+
+	return st
+}
+
+// WithTagName sets the struct tag holding the rules
+func WithTagName(tagName string) Option {
+	return func(st *StructSanitizer) {
+		st.tagName = tagName
+	}
+}
+
+// WithVerbose logs every sanitized field, to stdout unless a logger is set with WithLogger
+func WithVerbose(verbose bool) Option {
+	return func(st *StructSanitizer) {
+		st.verbose = verbose
+	}
+}
+
+// WithStrict returns ErrUnknownRule for rule names that are not registered instead of ignoring them
+func WithStrict(strict bool) Option {
+	return func(st *StructSanitizer) {
+		st.strict = strict
+	}
+}
+
+// WithRegistry uses the given registry for custom rules, the built-in rules are always available
+func WithRegistry(registry *Registry) Option {
+	return func(st *StructSanitizer) {
+		st.registry = registry
+	}
+}
+
+// WithMaxDepth limits how deep nested values are walked, deeper values fail once with ErrMaxDepth and 0 disables the limit
+func WithMaxDepth(depth int) Option {
+	return func(st *StructSanitizer) {
+		st.maxDepth = depth
+	}
+}
+
+// WithReject rejects every value the rules would change with ErrRejected instead of rewriting it,
+// a single field can do the same with the reject flag in its tag, e.g. sanitize:"xss, reject"
+func WithReject(reject bool) Option {
+	return func(st *StructSanitizer) {
+		st.reject = reject
+	}
+}
+
+// WithUnsettable sets what happens to tagged fields that cannot be set
+func WithUnsettable(policy UnsettablePolicy) Option {
+	return func(st *StructSanitizer) {
+		st.unsettable = policy
+	}
+}
+
+// WithLogger writes a debug record for every applied rule and a warning for every skipped field
+func WithLogger(logger *slog.Logger) Option {
+	return func(st *StructSanitizer) {
+		st.log = logger
+	}
+}
+
+// WithRedact leaves the field values out of the log records, only their lengths are logged
+func WithRedact(redact bool) Option {
+	return func(st *StructSanitizer) {
+		st.redact = redact
+	}
+}
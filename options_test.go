@@ -0,0 +1,225 @@
+package sanitizer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type node struct {
+	Name string `sanitize:"html"`
+	Next *node
+}
+
+// countingNode counts how many times it is sanitized
+type countingNode struct {
+	Name  string `sanitize:"html_escape"`
+	Self  *countingNode
+	calls int
+}
+
+func (n *countingNode) AfterSanitize() error {
+	n.calls++
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	st := New()
+	if st.tagName != defaultTagName || st.maxDepth != defaultMaxDepth || st.verbose || st.strict {
+		t.Errorf("New() defaults = %+v", st)
+	}
+
+	registry := NewRegistry()
+	if err := registry.Register("test_lower", func(input string) (string, error) {
+		return strings.ToLower(input), nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	st = New(
+		WithTagName("clean"),
+		WithVerbose(true),
+		WithStrict(true),
+		WithRegistry(registry),
+		WithMaxDepth(3),
+		WithUnsettable(UnsettableSkip),
+	)
+	if st.tagName != "clean" || !st.verbose || !st.strict || st.registry != registry || st.maxDepth != 3 || st.unsettable != UnsettableSkip {
+		t.Errorf("New() options = %+v", st)
+	}
+
+	payload := &struct {
+		Name  string `clean:"html, test_lower"`
+		Other string `sanitize:"html"`
+	}{
+		Name:  "<b>JOHN</b>",
+		Other: "<b>other</b>",
+	}
+
+	// The same instance can be reused
+	for i := 0; i < 2; i++ {
+		if err := st.SanitizeStruct(payload); err != nil {
+			t.Fatalf("SanitizeStruct() error = %v", err)
+		}
+	}
+
+	if payload.Name != "john" || payload.Other != "<b>other</b>" {
+		t.Errorf("SanitizeStruct() = %+v", payload)
+	}
+}
+
+func TestWithStrict(t *testing.T) {
+	payload := &struct {
+		Name string `sanitize:"html, unknown_rule"`
+	}{
+		Name: "<b>name</b>",
+	}
+
+	if err := New().SanitizeStruct(payload); err != nil {
+		t.Errorf("SanitizeStruct() unknown rule error = %v", err)
+	}
+
+	err := New(WithStrict(true)).SanitizeStruct(payload)
+	if !errors.Is(err, ErrUnknownRule) || !strings.Contains(err.Error(), "field Name") {
+		t.Errorf("SanitizeStruct() strict error = %v", err)
+	}
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	loop := &node{Name: "<b>loop</b>"}
+	loop.Next = loop
+
+	// Pointers already walked are skipped, so self references end without reaching the maximum depth
+	counting := &countingNode{Name: "<b>"}
+	counting.Self = counting
+	parent := &struct{ Node *countingNode }{Node: counting}
+
+	if err := New().SanitizeStruct(parent); err != nil || counting.Name != "&lt;b&gt;" || counting.calls != 1 {
+		t.Errorf("SanitizeStruct() self reference = %q, %d calls, error = %v", counting.Name, counting.calls, err)
+	}
+
+	counting.Name = "<b>"
+	if err := New().SanitizeStruct(counting); err != nil || counting.Name != "&lt;b&gt;" {
+		t.Errorf("SanitizeStruct() self referencing root = %q, error = %v", counting.Name, err)
+	}
+
+	if err := New().SanitizeStruct(loop); err != nil || loop.Name != "loop" {
+		t.Errorf("SanitizeStruct() self reference = %q, error = %v", loop.Name, err)
+	}
+
+	clean, err := Clean(loop)
+	if err != nil || clean.Next != clean {
+		t.Errorf("Clean() self reference = %p, want %p, error = %v", clean.Next, clean, err)
+	}
+
+	list := &node{Name: "<b>1</b>", Next: &node{Name: "<b>2</b>", Next: &node{Name: "<b>3</b>"}}}
+
+	// Deep values report the maximum depth once
+	var errs *Errors
+	if err := New(WithMaxDepth(2)).SanitizeStruct(list); !errors.As(err, &errs) || len(errs.Fields) != 1 || !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("SanitizeStruct() depth 2 error = %v", err)
+	}
+
+	if err := New(WithMaxDepth(0)).SanitizeStruct(list); err != nil {
+		t.Errorf("SanitizeStruct() unlimited depth error = %v", err)
+	}
+
+	if list.Next.Next.Name != "3" {
+		t.Errorf("SanitizeStruct() deepest node = %q", list.Next.Next.Name)
+	}
+}
+
+func TestWithUnsettable(t *testing.T) {
+	payload := &struct {
+		Name   string `sanitize:"html"`
+		secret string `sanitize:"html"`
+	}{
+		Name:   "<b>name</b>",
+		secret: "<b>secret</b>",
+	}
+
+	if err := New().SanitizeStruct(payload); !errors.Is(err, ErrUnsettable) {
+		t.Errorf("SanitizeStruct() error = %v, want ErrUnsettable", err)
+	}
+
+	var skipped *SkippedFieldsError
+	if err := New(WithUnsettable(UnsettableSkip)).SanitizeStruct(payload); !errors.As(err, &skipped) {
+		t.Errorf("SanitizeStruct() error = %v, want *SkippedFieldsError", err)
+	}
+
+	if payload.Name != "name" {
+		t.Errorf("Name sanitize error = %q", payload.Name)
+	}
+}
+
+func TestWithReject(t *testing.T) {
+	type rejectPayload struct {
+		Name    string            `sanitize:"html, alpha, reject"`
+		Clean   string            `sanitize:"html, reject"`
+		Comment string            `sanitize:"html"`
+		Labels  map[string]string `sanitize:"keys=alpha, values=xss, reject"`
+	}
+
+	newPayload := func() *rejectPayload {
+		return &rejectPayload{
+			Name:    "John 2",
+			Clean:   "clean",
+			Comment: "<b>comment</b>",
+			Labels:  map[string]string{"env1": "prod"},
+		}
+	}
+
+	t.Run("Per field", func(t *testing.T) {
+		payload := newPayload()
+
+		err := New().SanitizeStruct(payload)
+
+		want := &Errors{Fields: []*FieldError{
+			{Path: "Name", Rule: "alpha", Err: ErrRejected},
+			{Path: "Labels[env1]", Rule: "keys=alpha", Err: ErrRejected},
+		}}
+		if !reflect.DeepEqual(err, want) {
+			t.Errorf("SanitizeStruct() error = %v, want %v", err, want)
+		}
+
+		wantPayload := newPayload()
+		wantPayload.Comment = "comment"
+		if !reflect.DeepEqual(payload, wantPayload) {
+			t.Errorf("SanitizeStruct() = %+v, want %+v", payload, wantPayload)
+		}
+	})
+
+	t.Run("Global", func(t *testing.T) {
+		payload := newPayload()
+
+		err := New(WithReject(true)).SanitizeStruct(payload)
+		if !errors.Is(err, ErrRejected) {
+			t.Fatalf("SanitizeStruct() error = %v, want ErrRejected", err)
+		}
+
+		var errs *Errors
+		if errors.As(err, &errs) && len(errs.Fields) != 3 {
+			t.Errorf("SanitizeStruct() error = %v, want 3 rejected fields", err)
+		}
+
+		if !reflect.DeepEqual(payload, newPayload()) {
+			t.Errorf("SanitizeStruct() = %+v, want it untouched", payload)
+		}
+	})
+
+	t.Run("Value", func(t *testing.T) {
+		got, err := Value("<b>value</b>", "html", "reject")
+		if !errors.Is(err, ErrRejected) || got != "<b>value</b>" {
+			t.Errorf("Value() = %v, %v, want ErrRejected", got, err)
+		}
+	})
+
+	t.Run("Clean values pass", func(t *testing.T) {
+		payload := &rejectPayload{Name: "John", Clean: "clean", Labels: map[string]string{"env": "prod"}}
+
+		if err := New(WithReject(true)).SanitizeStruct(payload); err != nil {
+			t.Errorf("SanitizeStruct() error = %v", err)
+		}
+	})
+}
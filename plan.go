@@ -0,0 +1,149 @@
+package sanitizer
+
+import "reflect"
+
+// structPlan is the cached sanitization plan of a struct type
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// fieldPlan holds what to do with a single field, nested structs are flattened into their parent plan
+type fieldPlan struct {
+	index []int      // index path for reflect.Value.FieldByIndex
+	path  string     // field path relative to the planned struct, e.g. Address.ZipCode
+	rules fieldRules // rules resolved when the plan was built
+	err   error      // malformed tag
+}
+
+// compiledRule is a rule with its function resolved from the registry
+type compiledRule struct {
+	rule
+	fn  RuleFunc // nil when the rule was not registered while compiling, it is looked up again when applied
+	err error    // invalid arguments
+}
+
+// fieldRules holds the compiled rules of a struct tag
+type fieldRules struct {
+	values []compiledRule // applied to strings, slice elements and map values
+	keys   []compiledRule // applied to map keys
+	reject bool           // values the rules would change fail instead of being rewritten
+}
+
+// empty reports whether there are no rules at all
+func (fr fieldRules) empty() bool {
+	return len(fr.values) == 0 && len(fr.keys) == 0
+}
+
+// plan returns the cached plan of a struct type, building it on first use
+func (st *StructSanitizer) plan(t reflect.Type) *structPlan {
+	if p, ok := st.plans.Load(t); ok {
+		return p.(*structPlan)
+	}
+
+	p := &structPlan{}
+	st.compileFields(p, t, nil, "")
+
+	actual, _ := st.plans.LoadOrStore(t, p)
+
+	return actual.(*structPlan)
+}
+
+// resetPlans drops every cached plan, rules registered afterwards are resolved again
+func (st *StructSanitizer) resetPlans() {
+	st.plans.Range(func(key, _ any) bool {
+		st.plans.Delete(key)
+		return true
+	})
+}
+
+// compileFields adds the fields of t to the plan, index and path lead from the planned struct to t
+func (st *StructSanitizer) compileFields(p *structPlan, t reflect.Type, index []int, path string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+		fp := fieldPlan{index: fieldIndex, path: fieldPath(path, field.Name)}
+
+		tr, err := parseTag(field.Tag.Get(st.tag()))
+		if err != nil {
+			fp.err = err
+			p.fields = append(p.fields, fp)
+			continue
+		}
+
+		// Nested structs are flattened, their tag is not used, structs with hooks are walked to call them
+		if field.Type.Kind() == reflect.Struct && typeHooks(field.Type) == 0 {
+			st.compileFields(p, field.Type, fieldIndex, fp.path)
+			continue
+		}
+
+		// Fields that can never hold a string or a value with hooks are skipped
+		if !containsStrings(field.Type, make(map[reflect.Type]bool)) {
+			continue
+		}
+
+		fp.rules = st.compileRules(tr)
+		p.fields = append(p.fields, fp)
+	}
+}
+
+// compileRules resolves the rule functions of a parsed tag
+func (st *StructSanitizer) compileRules(tr tagRules) fieldRules {
+	return fieldRules{
+		values: st.compileRuleList(tr.values),
+		keys:   st.compileRuleList(tr.keys),
+		reject: tr.reject || st.reject,
+	}
+}
+
+// compileRuleList resolves every rule of the list
+func (st *StructSanitizer) compileRuleList(rules []rule) []compiledRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	compiled := make([]compiledRule, len(rules))
+	for i, r := range rules {
+		compiled[i].rule = r
+
+		if factory, ok := st.lookupRule(r.name); ok {
+			compiled[i].fn, compiled[i].err = factory(r.args)
+		}
+	}
+
+	return compiled
+}
+
+// containsStrings reports whether a value of type t can hold a string or a value with hooks somewhere inside,
+// visiting holds the types being checked so recursive types end
+func containsStrings(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+
+	if typeHooks(t) != 0 {
+		return true
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return true
+
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsStrings(t.Elem(), visiting)
+
+	case reflect.Map:
+		return containsStrings(t.Key(), visiting) || containsStrings(t.Elem(), visiting)
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsStrings(t.Field(i).Type, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package sanitizer
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type planA struct {
+	Next *planB
+	Name string
+}
+
+type planB struct {
+	A *planA
+}
+
+func TestPlan(t *testing.T) {
+	st := New()
+
+	p := st.plan(reflect.TypeOf(Payload{}))
+	if p != st.plan(reflect.TypeOf(Payload{})) {
+		t.Errorf("plan() not cached")
+	}
+
+	var paths []string
+	for _, field := range p.fields {
+		paths = append(paths, field.path)
+	}
+
+	want := []string{
+		"FirstName", "LastName", "Website", "Username",
+		"Address.StreetAddress1", "Address.StreetAddress2", "Address.City", "Address.State", "Address.ZipCode",
+		"Tags", "Comments", "Escape", "Alpha", "AlphaNum",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("plan() paths = %v, want %v", paths, want)
+	}
+
+	for _, field := range p.fields {
+		if field.path == "Address.ZipCode" && !reflect.DeepEqual(field.index, []int{5, 4}) {
+			t.Errorf("plan() Address.ZipCode index = %v", field.index)
+		}
+		if field.path == "FirstName" && (len(field.rules.values) != 1 || field.rules.values[0].fn == nil) {
+			t.Errorf("plan() FirstName rules not resolved = %+v", field.rules)
+		}
+	}
+}
+
+func TestContainsStrings(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  bool
+	}{
+		{name: "String", value: "", want: true},
+		{name: "Int", value: 0, want: false},
+		{name: "Bool pointer", value: new(bool), want: false},
+		{name: "Int slice", value: []int{}, want: false},
+		{name: "String array", value: [2]string{}, want: true},
+		{name: "String keys", value: map[string]int{}, want: true},
+		{name: "Interface values", value: map[int]any{}, want: true},
+		{name: "Struct without strings", value: struct{ A, B int }{}, want: false},
+		{name: "Recursive types", value: planB{}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsStrings(reflect.TypeOf(tt.value), make(map[reflect.Type]bool)); got != tt.want {
+				t.Errorf("containsStrings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanLateRegistration(t *testing.T) {
+	type late struct {
+		Name string `sanitize:"test_late_upper"`
+	}
+
+	st := New()
+
+	payload := &late{Name: "name"}
+	if err := st.SanitizeStruct(payload); err != nil || payload.Name != "name" {
+		t.Fatalf("SanitizeStruct() = %q, %v", payload.Name, err)
+	}
+
+	// Rules registered after the plan was built are still found
+	if err := Register("test_late_upper", func(input string) (string, error) {
+		return strings.ToUpper(input), nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if err := st.SanitizeStruct(payload); err != nil || payload.Name != "NAME" {
+		t.Errorf("SanitizeStruct() = %q, %v", payload.Name, err)
+	}
+}
+
+func TestPlanConcurrency(t *testing.T) {
+	st := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			payload := benchmarkPayload()
+			if err := st.SanitizeStruct(payload); err != nil {
+				t.Errorf("SanitizeStruct() error = %v", err)
+			}
+			if payload.Address.ZipCode != "SW1W 0NY" {
+				t.Errorf("ZipCode sanitize error = %q", payload.Address.ZipCode)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func benchmarkPayload() *Payload {
+	return &Payload{
+		FirstName: `First <script>$("#something").hide()</script>Name 123`,
+		LastName:  `Last <embed width="50" class="something"></embed>Name`,
+		Age:       30,
+		Website:   "https://www.domain.com",
+		Username:  "/This/Works/?that=123&this#page10%",
+		Address: Address{
+			StreetAddress1: `<p>Street Address 1</p>`,
+			City:           `<CustomTags>City</CustomTags>`,
+			ZipCode:        `SW1W 0NY<script>$("#something").hide()</script>`,
+		},
+		Tags:     []string{`first<script>alert(1)</script>`, `second`},
+		Comments: []string{`hello <b>world</b>`},
+		Escape:   `<h1>Escape me!</h1>`,
+		Alpha:    "Just letters 123",
+		AlphaNum: "Letters and 123 !@#",
+	}
+}
+
+// BenchmarkSanitizeStructCached reuses one sanitizer, the plan of Payload is built once
+func BenchmarkSanitizeStructCached(b *testing.B) {
+	st := New()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := st.SanitizeStruct(benchmarkPayload()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSanitizeStructUncached builds a new sanitizer every time, paying the reflection cost like the previous walker
+func BenchmarkSanitizeStructUncached(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := New().SanitizeStruct(benchmarkPayload()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
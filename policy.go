@@ -0,0 +1,233 @@
+package sanitizer
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// Policy is an allowlist of HTML elements, their attributes and the URL schemes of links, create one with NewPolicy.
+// A policy is not changed after it is created so it can be shared, use With to extend it.
+type Policy struct {
+	elements map[string]map[string]bool // allowed elements with their allowed attributes
+	schemes  map[string]bool            // URL schemes allowed in href and src, relative URLs are always allowed
+}
+
+// PolicyOption configures a Policy created with NewPolicy
+type PolicyOption func(*Policy)
+
+// urlAttributes hold URLs, their scheme has to be allowed by the policy
+var urlAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"cite":       true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"background": true,
+	"longdesc":   true,
+	"xlink:href": true,
+}
+
+// textEscaper escapes decoded text so it is read back as the same text
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var (
+	strictPolicy = NewPolicy()
+
+	ugcPolicy = NewPolicy(
+		AllowElements("b", "i", "em", "strong", "u", "s", "p", "br", "ul", "ol", "li", "blockquote", "code", "pre"),
+		AllowAttributes("a", "href", "title"),
+		AllowURLSchemes("http", "https", "mailto"),
+	)
+
+	// policies can be used in struct tags, e.g. html(policy=ugc)
+	policies = map[string]*Policy{
+		"strict": strictPolicy,
+		"ugc":    ugcPolicy,
+	}
+)
+
+// StrictPolicy removes every element and keeps the text
+func StrictPolicy() *Policy {
+	return strictPolicy
+}
+
+// UGCPolicy keeps the formatting of user generated content like comments, b, i, p, lists and links to http, https and mailto URLs
+func UGCPolicy() *Policy {
+	return ugcPolicy
+}
+
+// NewPolicy creates a policy, without options every element is removed
+func NewPolicy(opts ...PolicyOption) *Policy {
+	p := &Policy{
+		elements: map[string]map[string]bool{},
+		schemes:  map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// With returns a copy of the policy extended with opts
+func (p *Policy) With(opts ...PolicyOption) *Policy {
+	copied := NewPolicy()
+
+	for element, attrs := range p.elements {
+		copied.elements[element] = map[string]bool{}
+		for attr := range attrs {
+			copied.elements[element][attr] = true
+		}
+	}
+
+	for scheme := range p.schemes {
+		copied.schemes[scheme] = true
+	}
+
+	for _, opt := range opts {
+		opt(copied)
+	}
+
+	return copied
+}
+
+// AllowElements keeps the given elements without attributes
+func AllowElements(names ...string) PolicyOption {
+	return func(p *Policy) {
+		for _, name := range names {
+			p.allowElement(name)
+		}
+	}
+}
+
+// AllowAttributes keeps the element with the given attributes, e.g. AllowAttributes("a", "href")
+func AllowAttributes(element string, attrs ...string) PolicyOption {
+	return func(p *Policy) {
+		allowed := p.allowElement(element)
+		for _, attr := range attrs {
+			allowed[strings.ToLower(attr)] = true
+		}
+	}
+}
+
+// AllowURLSchemes allows the given schemes in href, src and the other URL attributes
+func AllowURLSchemes(schemes ...string) PolicyOption {
+	return func(p *Policy) {
+		for _, scheme := range schemes {
+			p.schemes[strings.ToLower(scheme)] = true
+		}
+	}
+}
+
+// allowElement adds an element to the policy and returns its allowed attributes
+func (p *Policy) allowElement(name string) map[string]bool {
+	name = strings.ToLower(name)
+	if p.elements[name] == nil {
+		p.elements[name] = map[string]bool{}
+	}
+
+	return p.elements[name]
+}
+
+// Sanitize removes the elements and attributes the policy does not allow, the content of removed
+// elements is kept except for script, style and the other raw text elements. Text is escaped.
+func (p *Policy) Sanitize(input string) string {
+	var b strings.Builder
+	b.Grow(len(input))
+
+	z := newTokenizer(input, false)
+	for {
+		t, ok := z.next()
+		if !ok {
+			break
+		}
+
+		switch t.typ {
+		case textToken:
+			b.WriteString(textEscaper.Replace(html.UnescapeString(t.raw)))
+
+		case rawTextToken:
+			if _, ok := p.elements[t.name]; ok {
+				text := t.raw
+				if t.name == "textarea" || t.name == "title" {
+					text = html.UnescapeString(text)
+				}
+				b.WriteString(textEscaper.Replace(text))
+			}
+
+		case startTagToken, selfClosingTagToken:
+			if attrs, ok := p.elements[t.name]; ok && !t.eof {
+				p.writeTag(&b, t, attrs)
+			}
+
+		case endTagToken:
+			if _, ok := p.elements[t.name]; ok {
+				b.WriteString("</" + t.name + ">")
+			}
+
+		case commentToken:
+			// Unterminated declarations like <?@ cannot become tags, they are kept as text
+			if t.eof {
+				b.WriteString(textEscaper.Replace(t.raw))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// writeTag writes a start tag with its allowed attributes, the first of duplicated attributes wins like in browsers
+func (p *Policy) writeTag(b *strings.Builder, t token, allowed map[string]bool) {
+	b.WriteString("<" + t.name)
+
+	seen := map[string]bool{}
+	for _, a := range t.attrs {
+		if !allowed[a.name] || seen[a.name] {
+			continue
+		}
+		seen[a.name] = true
+
+		value := html.UnescapeString(a.value)
+		if urlAttributes[a.name] && !p.allowedURL(value) {
+			continue
+		}
+
+		b.WriteString(" " + a.name + `="` + html.EscapeString(value) + `"`)
+	}
+
+	if t.typ == selfClosingTagToken {
+		b.WriteString("/>")
+		return
+	}
+
+	b.WriteString(">")
+}
+
+// allowedURL reports whether a URL is relative or uses an allowed scheme,
+// tabs, new lines and leading control characters are ignored by browsers so they are removed first
+func (p *Policy) allowedURL(value string) bool {
+	value = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, value)
+
+	value = strings.TrimFunc(value, func(r rune) bool {
+		return r <= ' '
+	})
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" {
+		return true
+	}
+
+	return p.schemes[strings.ToLower(u.Scheme)]
+}
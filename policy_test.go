@@ -0,0 +1,89 @@
+package sanitizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicySanitize(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		input  string
+		want   string
+	}{
+		{name: "Strict keeps text", policy: StrictPolicy(), input: `<p>Hello <b>world</b></p>`, want: "Hello world"},
+		{name: "Strict escapes text", policy: StrictPolicy(), input: `3 < 4 &amp; 5 > 2`, want: "3 &lt; 4 &amp; 5 &gt; 2"},
+		{name: "Strict drops scripts", policy: StrictPolicy(), input: `a<script>alert("<b>")</script>b<style>p{}</style>`, want: "ab"},
+		{name: "Strict decodes entities", policy: StrictPolicy(), input: `&lt;script&gt; &quot;quoted&quot; don't`, want: `&lt;script&gt; "quoted" don't`},
+		{name: "UGC keeps formatting", policy: UGCPolicy(), input: `<p>Hi <b>bold</b> <i>it</i><br/></p>`, want: `<p>Hi <b>bold</b> <i>it</i><br/></p>`},
+		{name: "UGC drops attributes", policy: UGCPolicy(), input: `<p class="x" onclick="alert(1)">text</p>`, want: `<p>text</p>`},
+		{name: "UGC drops other elements", policy: UGCPolicy(), input: `<div><img src=x onerror=alert(1)>text</div>`, want: "text"},
+		{name: "UGC keeps links", policy: UGCPolicy(), input: `<a href="https://example.com/?a=1&amp;b=2" target="_blank">link</a>`, want: `<a href="https://example.com/?a=1&amp;b=2">link</a>`},
+		{name: "UGC keeps relative links", policy: UGCPolicy(), input: `<a href="/page">link</a>`, want: `<a href="/page">link</a>`},
+		{name: "UGC drops javascript links", policy: UGCPolicy(), input: `<a href="javascript:alert(1)">link</a>`, want: `<a>link</a>`},
+		{name: "UGC drops obfuscated javascript links", policy: UGCPolicy(), input: `<a href=" java&#x09;script&colon;alert(1)">link</a>`, want: `<a>link</a>`},
+		{name: "UGC drops data links", policy: UGCPolicy(), input: `<a href="DATA:text/html,x">link</a>`, want: `<a>link</a>`},
+		{name: "UGC keeps the first duplicated attribute", policy: UGCPolicy(), input: `<a href="/a" href="javascript:x">link</a>`, want: `<a href="/a">link</a>`},
+		{name: "UGC escapes attribute values", policy: UGCPolicy(), input: `<a title='"><script>'>x</a>`, want: `<a title="&#34;&gt;&lt;script&gt;">x</a>`},
+		{name: "Comments are removed", policy: UGCPolicy(), input: `a<!-- <b>hidden</b> -->b`, want: "ab"},
+		{name: "Unterminated tags are removed", policy: UGCPolicy(), input: `a<b`, want: "a"},
+		{
+			name:   "Custom policy",
+			policy: NewPolicy(AllowAttributes("img", "src", "alt"), AllowURLSchemes("https")),
+			input:  `<img src="https://example.com/a.png" alt="a"><img src="http://example.com/b.png">`,
+			want:   `<img src="https://example.com/a.png" alt="a"><img>`,
+		},
+		{
+			name:   "Extended preset",
+			policy: UGCPolicy().With(AllowElements("h1")),
+			input:  `<h1>Title</h1><h2>Sub</h2>`,
+			want:   `<h1>Title</h1>Sub`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Policy.Sanitize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyWith(t *testing.T) {
+	extended := UGCPolicy().With(AllowElements("h1"))
+
+	if UGCPolicy().Sanitize("<h1>x</h1>") != "x" {
+		t.Errorf("Policy.With() changed the original policy")
+	}
+	if extended.Sanitize("<h1>x</h1>") != "<h1>x</h1>" {
+		t.Errorf("Policy.With() did not extend the policy")
+	}
+}
+
+func TestStructHTMLPolicy(t *testing.T) {
+	payload := &struct {
+		Comment string `sanitize:"html(policy=ugc)"`
+		Title   string `sanitize:"html(policy=strict)"`
+		Body    string `sanitize:"html"`
+	}{
+		Comment: `<p onclick="x()">Nice <b>post</b></p><script>alert(1)</script>`,
+		Title:   `<b>Title</b> &amp; more`,
+		Body:    `<p>Body</p>`,
+	}
+
+	if err := New().SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if payload.Comment != "<p>Nice <b>post</b></p>" || payload.Title != "Title &amp; more" || payload.Body != "Body" {
+		t.Errorf("SanitizeStruct() = %+v", payload)
+	}
+
+	err := New().SanitizeStruct(&struct {
+		Comment string `sanitize:"html(policy=unknown)"`
+	}{})
+	if err == nil || !strings.Contains(err.Error(), "policy=unknown must be strict or ugc") {
+		t.Errorf("SanitizeStruct() error = %v, want an unknown policy error", err)
+	}
+}
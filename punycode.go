@@ -0,0 +1,375 @@
+package sanitizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Punycode parameters from RFC 3492
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+
+	acePrefix = "xn--" // prefix of the labels encoded with Punycode, called A-labels
+)
+
+// errPunycodeOverflow is returned for labels whose code points do not fit the Punycode counters
+var errPunycodeOverflow = errors.New("punycode: overflow")
+
+// labelSeparators are the full stops browsers read as the dot between domain labels
+var labelSeparators = strings.NewReplacer(
+	string(rune(0x3002)), ".", // ideographic full stop
+	string(rune(0xff0e)), ".", // fullwidth full stop
+	string(rune(0xff61)), ".", // halfwidth ideographic full stop
+)
+
+// ToASCII converts an internationalized domain name to its ASCII form, labels with other characters than ASCII
+// are lower cased and encoded with Punycode, e.g. münchen.de becomes xn--mnchen-3ya.de.
+// The input is not normalized, it is expected in NFC like browsers send it.
+func ToASCII(domain string) (string, error) {
+	labels := strings.Split(labelSeparators.Replace(domain), ".")
+
+	for i, label := range labels {
+		if isASCII(label) {
+			labels[i] = strings.ToLower(label)
+			continue
+		}
+
+		// Lower casing would hide invalid bytes behind U+FFFD
+		if !utf8.ValidString(label) {
+			return domain, fmt.Errorf("invalid domain label %q: invalid UTF-8", label)
+		}
+
+		encoded, err := punycodeEncode(strings.ToLower(label))
+		if err != nil {
+			return domain, fmt.Errorf("invalid domain label %q: %w", label, err)
+		}
+		labels[i] = acePrefix + encoded
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+// ToUnicode converts the Punycode labels of a domain name back to Unicode, e.g. xn--mnchen-3ya.de becomes münchen.de
+func ToUnicode(domain string) (string, error) {
+	labels := strings.Split(domain, ".")
+
+	for i, label := range labels {
+		if len(label) < len(acePrefix) || !strings.EqualFold(label[:len(acePrefix)], acePrefix) {
+			continue
+		}
+
+		decoded, err := punycodeDecode(strings.ToLower(label[len(acePrefix):]))
+		if err != nil {
+			return domain, fmt.Errorf("invalid domain label %q: %w", label, err)
+		}
+		labels[i] = decoded
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+// homographScripts are the scripts a single label may combine, following the highly restrictive level of
+// Unicode TS 39: Japanese, Chinese and Korean are written with several scripts and Latin
+var homographScripts = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// IsHomograph reports whether a label of the domain mixes scripts, like a Cyrillic а in an otherwise Latin pаypal.com,
+// a common way to imitate another domain. Punycode labels are decoded first, digits and hyphens belong to every script.
+func IsHomograph(domain string) bool {
+	if decoded, err := ToUnicode(domain); err == nil {
+		domain = decoded
+	}
+
+	for _, label := range strings.Split(labelSeparators.Replace(domain), ".") {
+		if mixedScripts(label) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RejectHomograph passes through the result of URL or Domain and fails with ErrHomograph when the host mixes scripts,
+// e.g. RejectHomograph(Domain(input, true)). Errors given to it are returned as they are.
+func RejectHomograph(value string, err error) (string, error) {
+	if err != nil {
+		return value, err
+	}
+
+	host := value
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil {
+			host = u.Hostname()
+		}
+	}
+
+	if IsHomograph(host) {
+		return value, fmt.Errorf("%w: %s", ErrHomograph, host)
+	}
+
+	return value, nil
+}
+
+// mixedScripts reports whether the letters of label come from scripts that are not used together
+func mixedScripts(label string) bool {
+	scripts := map[string]bool{}
+	for _, r := range label {
+		if name := scriptOf(r); name != "" {
+			scripts[name] = true
+		}
+	}
+
+	if len(scripts) < 2 {
+		return false
+	}
+
+	for _, allowed := range homographScripts {
+		if containsAll(allowed, scripts) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scriptOf returns the script of a letter, or an empty string for digits, punctuation and other shared characters
+func scriptOf(r rune) string {
+	if !unicode.IsLetter(r) && !unicode.IsMark(r) {
+		return ""
+	}
+
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// containsAll reports whether every script in scripts is listed in allowed
+func containsAll(allowed []string, scripts map[string]bool) bool {
+	for name := range scripts {
+		found := false
+		for _, a := range allowed {
+			found = found || a == name
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// punycodeEncode encodes a label with the Punycode algorithm of RFC 3492, without the xn-- prefix
+func punycodeEncode(label string) (string, error) {
+	runes := []rune(label)
+
+	var b strings.Builder
+	for _, r := range runes {
+		if r < 0x80 {
+			b.WriteRune(r)
+		}
+	}
+
+	basic := b.Len()
+	if basic > 0 {
+		b.WriteByte('-')
+	}
+
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for handled := basic; handled < len(runes); {
+		// The smallest code point not handled yet
+		m := math.MaxInt32
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+
+		if (m - n) > (math.MaxInt32-delta)/(handled+1) {
+			return "", errPunycodeOverflow
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+				if delta == math.MaxInt32 {
+					return "", errPunycodeOverflow
+				}
+			}
+
+			if int(r) != n {
+				continue
+			}
+
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := punycodeThreshold(k, bias)
+				if q < t {
+					break
+				}
+				b.WriteByte(punycodeDigit(t + (q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			b.WriteByte(punycodeDigit(q))
+
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+
+		delta++
+		n++
+	}
+
+	return b.String(), nil
+}
+
+// punycodeDecode decodes a label encoded with punycodeEncode
+func punycodeDecode(encoded string) (string, error) {
+	var output []rune
+
+	pos := 0
+	if delimiter := strings.LastIndexByte(encoded, '-'); delimiter >= 0 {
+		for i := 0; i < delimiter; i++ {
+			if encoded[i] >= 0x80 {
+				return "", errors.New("punycode: invalid basic code point")
+			}
+			output = append(output, rune(encoded[i]))
+		}
+		pos = delimiter + 1
+	}
+
+	n, i, bias := punycodeInitialN, 0, punycodeInitialBias
+	for pos < len(encoded) {
+		oldi, w := i, 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if pos >= len(encoded) {
+				return "", errors.New("punycode: truncated input")
+			}
+
+			digit, ok := punycodeDigitValue(encoded[pos])
+			pos++
+			if !ok {
+				return "", fmt.Errorf("punycode: invalid digit %q", encoded[pos-1])
+			}
+
+			if digit > (math.MaxInt32-i)/w {
+				return "", errPunycodeOverflow
+			}
+			i += digit * w
+
+			t := punycodeThreshold(k, bias)
+			if digit < t {
+				break
+			}
+
+			if w > math.MaxInt32/(punycodeBase-t) {
+				return "", errPunycodeOverflow
+			}
+			w *= punycodeBase - t
+		}
+
+		length := len(output) + 1
+		bias = punycodeAdapt(i-oldi, length, oldi == 0)
+
+		if i/length > math.MaxInt32-n {
+			return "", errPunycodeOverflow
+		}
+		n += i / length
+		i %= length
+
+		if n > unicode.MaxRune || (n >= 0xd800 && n <= 0xdfff) {
+			return "", fmt.Errorf("punycode: invalid code point %U", n)
+		}
+
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = rune(n)
+		i++
+	}
+
+	return string(output), nil
+}
+
+// punycodeThreshold clamps k - bias between tmin and tmax
+func punycodeThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punycodeTMin
+	case k >= bias+punycodeTMax:
+		return punycodeTMax
+	}
+
+	return k - bias
+}
+
+// punycodeAdapt is the bias adaptation function of RFC 3492 section 6.1
+func punycodeAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+// punycodeDigit returns the lower case character of a digit from 0 to 35
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+
+	return byte('0' + d - 26)
+}
+
+// punycodeDigitValue returns the value of a Punycode digit, upper case letters are accepted
+func punycodeDigitValue(c byte) (int, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A'), true
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26, true
+	}
+
+	return 0, false
+}
+
+// isASCII reports whether s only holds ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,156 @@
+package sanitizer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPunycode(t *testing.T) {
+	// Samples from RFC 3492 section 7.1 and common domain labels
+	tests := []struct {
+		name    string
+		decoded string
+		encoded string
+	}{
+		{name: "German", decoded: "bücher", encoded: "bcher-kva"},
+		{name: "Umlaut", decoded: "münchen", encoded: "mnchen-3ya"},
+		{name: "Japanese", decoded: "例え", encoded: "r8jz45g"},
+		{name: "Arabic", decoded: "ليهمابتكلموشعربي؟", encoded: "egbpdaj6bu4bxfgehfvwxn"},
+		{name: "Chinese", decoded: "他们为什么不说中文", encoded: "ihqwcrb4cv8a8dqg056pqjye"},
+		{name: "Russian", decoded: "почемужеонинеговорятпорусски", encoded: "b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+		{name: "Mixed with ASCII", decoded: "3年b組金八先生", encoded: "3b-ww4c5e180e575a65lsy2b"},
+		{name: "Only ASCII", decoded: "abc", encoded: "abc-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := punycodeEncode(tt.decoded)
+			if err != nil || encoded != tt.encoded {
+				t.Errorf("punycodeEncode() = %s, %v, want %s", encoded, err, tt.encoded)
+			}
+
+			decoded, err := punycodeDecode(tt.encoded)
+			if err != nil || decoded != tt.decoded {
+				t.Errorf("punycodeDecode() = %s, %v, want %s", decoded, err, tt.decoded)
+			}
+		})
+	}
+}
+
+func TestPunycodeDecodeErrors(t *testing.T) {
+	for _, encoded := range []string{"a-b!", "z", "99999999999999", "ü-abc"} {
+		if decoded, err := punycodeDecode(encoded); err == nil {
+			t.Errorf("punycodeDecode(%q) = %q, want an error", encoded, decoded)
+		}
+	}
+}
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "ASCII is lower cased", input: "Example.COM", want: "example.com"},
+		{name: "German", input: "München.de", want: "xn--mnchen-3ya.de"},
+		{name: "Japanese", input: "例え.jp", want: "xn--r8jz45g.jp"},
+		{name: "Ideographic full stop", input: "例え。jp", want: "xn--r8jz45g.jp"},
+		{name: "Punycode is kept", input: "XN--mnchen-3ya.de", want: "xn--mnchen-3ya.de"},
+		{name: "Invalid UTF-8", input: "b\xffcher.de", want: "b\xffcher.de", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToASCII(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ToASCII() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ToASCII() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "ASCII is kept", input: "example.com", want: "example.com"},
+		{name: "German", input: "xn--mnchen-3ya.de", want: "münchen.de"},
+		{name: "Upper case prefix", input: "XN--R8JZ45G.jp", want: "例え.jp"},
+		{name: "Invalid Punycode", input: "xn--a-b!.com", want: "xn--a-b!.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToUnicode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ToUnicode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ToUnicode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHomograph(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "ASCII", input: "paypal.com", want: false},
+		{name: "Single script", input: "münchen.de", want: false},
+		{name: "Cyrillic only", input: "пример.рф", want: false},
+		{name: "Japanese scripts", input: "例え.jp", want: false},
+		{name: "Japanese with Latin", input: "ソニーsony.jp", want: false},
+		{name: "Digits and hyphens", input: "xn--80akhbyknj4f-1.com", want: false},
+		{name: "Cyrillic a in Latin", input: "p" + string(rune(0x430)) + "ypal.com", want: true},
+		{name: "Greek omicron in Latin", input: "g" + string(rune(0x3bf)) + "ogle.com", want: true},
+		{name: "Punycode is decoded", input: "xn--pypal-4ve.com", want: true},
+		{name: "Scripts in different labels", input: "пример.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHomograph(tt.input); got != tt.want {
+				t.Errorf("IsHomograph(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRejectHomograph(t *testing.T) {
+	cyrillic := "p" + string(rune(0x430)) + "ypal.com"
+	invalid := errors.New("invalid domain")
+
+	tests := []struct {
+		name    string
+		value   string
+		err     error
+		wantErr error
+	}{
+		{name: "Single script domain", value: "xn--mnchen-3ya.de"},
+		{name: "Single script URL", value: "https://xn--mnchen-3ya.de/path"},
+		{name: "Mixed scripts domain", value: "xn--pypal-4ve.com", wantErr: ErrHomograph},
+		{name: "Mixed scripts URL", value: "https://xn--pypal-4ve.com:8080/login", wantErr: ErrHomograph},
+		{name: "Unicode domain", value: cyrillic, wantErr: ErrHomograph},
+		{name: "Errors are passed through", value: cyrillic, err: invalid, wantErr: invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RejectHomograph(tt.value, tt.err)
+			if got != tt.value || !errors.Is(err, tt.wantErr) {
+				t.Errorf("RejectHomograph(%q) = %q, %v, want error %v", tt.value, got, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := RejectHomograph(Domain(cyrillic, true)); !errors.Is(err, ErrHomograph) {
+		t.Errorf("RejectHomograph(Domain(%q)) error = %v", cyrillic, err)
+	}
+}
@@ -0,0 +1,359 @@
+package sanitizer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrDuplicateRule is returned when a rule name is registered twice
+	ErrDuplicateRule = errors.New("sanitize rule already registered")
+
+	// ErrUnknownRule is returned in strict mode when a tag uses a rule that is not registered
+	ErrUnknownRule = errors.New("unknown sanitize rule")
+)
+
+// RuleFunc sanitizes a single string value
+type RuleFunc func(input string) (string, error)
+
+// RuleFactory builds a rule from the arguments given in a struct tag, e.g. truncate(64)
+type RuleFactory func(args Args) (RuleFunc, error)
+
+// Registry holds named rules that can be referenced from struct tags
+type Registry struct {
+	mu    sync.RWMutex
+	rules map[string]RuleFactory
+}
+
+// defaultRegistry holds the built-in rules and every rule added with Register
+var defaultRegistry = newBuiltinRegistry()
+
+// NewRegistry creates an empty rule registry
+func NewRegistry() *Registry {
+	return &Registry{
+		rules: make(map[string]RuleFactory),
+	}
+}
+
+// Register adds a rule to the package-level registry shared by every sanitizer
+func Register(name string, fn func(string) (string, error)) error {
+	return defaultRegistry.Register(name, fn)
+}
+
+// RegisterFactory adds a rule that accepts arguments to the package-level registry
+func RegisterFactory(name string, factory RuleFactory) error {
+	return defaultRegistry.RegisterFactory(name, factory)
+}
+
+// Register adds a named rule without arguments, names can only be registered once
+func (r *Registry) Register(name string, fn func(string) (string, error)) error {
+	if fn == nil {
+		return fmt.Errorf("sanitize rule %s has no function", name)
+	}
+
+	return r.RegisterFactory(name, noArgs(name, fn))
+}
+
+// RegisterFactory adds a named rule that accepts arguments, names can only be registered once.
+// Registries created with NewRegistry cannot replace the built-in and package-level rules either.
+func (r *Registry) RegisterFactory(name string, factory RuleFactory) error {
+	if err := validateRuleName(name); err != nil {
+		return err
+	}
+
+	if factory == nil {
+		return fmt.Errorf("sanitize rule %s has no function", name)
+	}
+
+	if r != defaultRegistry {
+		if _, ok := defaultRegistry.Lookup(name); ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateRule, name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateRule, name)
+	}
+
+	r.rules[name] = factory
+
+	return nil
+}
+
+// Lookup returns the rule factory registered under name
+func (r *Registry) Lookup(name string) (RuleFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	factory, ok := r.rules[name]
+
+	return factory, ok
+}
+
+// noArgs wraps a plain rule into a factory that rejects any argument
+func noArgs(name string, fn RuleFunc) RuleFactory {
+	return func(args Args) (RuleFunc, error) {
+		if !args.Empty() {
+			return nil, fmt.Errorf("rule %s does not take arguments", name)
+		}
+
+		return fn, nil
+	}
+}
+
+// validateRuleName makes sure a rule name can be written inside a struct tag
+func validateRuleName(name string) error {
+	if name == "" {
+		return errors.New("sanitize rule name is empty")
+	}
+
+	if strings.ContainsAny(name, ruleSeparator+"()= \t\"") {
+		return fmt.Errorf("invalid sanitize rule name %q", name)
+	}
+
+	if name == rejectOption {
+		return fmt.Errorf("sanitize rule name %s is reserved", name)
+	}
+
+	return nil
+}
+
+// newBuiltinRegistry registers the rules shipped with the package
+func newBuiltinRegistry() *Registry {
+	r := NewRegistry()
+
+	r.rules[htmlField] = htmlRule
+
+	r.rules[xmlField] = xmlRule
+
+	r.rules[htmlEscapeField] = noArgs(htmlEscapeField, func(input string) (string, error) {
+		return HtmlEscape(input), nil
+	})
+
+	r.rules[scriptsField] = noArgs(scriptsField, func(input string) (string, error) {
+		return Scripts(input), nil
+	})
+
+	r.rules[uriField] = noArgs(uriField, func(input string) (string, error) {
+		return URI(input), nil
+	})
+
+	r.rules[xssField] = noArgs(xssField, func(input string) (string, error) {
+		return XSS(input), nil
+	})
+
+	r.rules[attrsField] = noArgs(attrsField, func(input string) (string, error) {
+		return Attributes(input), nil
+	})
+
+	r.rules[htmlAttrEscapeField] = noArgs(htmlAttrEscapeField, func(input string) (string, error) {
+		return EscapeHTMLAttr(input), nil
+	})
+
+	r.rules[jsEscapeField] = noArgs(jsEscapeField, func(input string) (string, error) {
+		return EscapeJSString(input), nil
+	})
+
+	r.rules[cssEscapeField] = noArgs(cssEscapeField, func(input string) (string, error) {
+		return EscapeCSSString(input), nil
+	})
+
+	r.rules[urlEscapeField] = noArgs(urlEscapeField, func(input string) (string, error) {
+		return EscapeURLComponent(input), nil
+	})
+
+	r.rules[jsonScriptEscapeField] = noArgs(jsonScriptEscapeField, func(input string) (string, error) {
+		return EscapeJSONForScript(input), nil
+	})
+
+	r.rules[urlField] = urlRule
+	r.rules[domainField] = domainRule
+	r.rules[alphaField] = alphaRule
+	r.rules[alphanumericField] = alphaNumericRule
+	r.rules[truncateField] = truncateRule
+
+	return r
+}
+
+// htmlRule builds html, html(decode=true) or html(policy=ugc), without a policy every tag is removed
+func htmlRule(args Args) (RuleFunc, error) {
+	if err := args.only(0, "policy", "decode"); err != nil {
+		return nil, err
+	}
+
+	decode, err := args.Bool("decode", false)
+	if err != nil {
+		return nil, err
+	}
+
+	name, ok := args.Named["policy"]
+	if !ok {
+		return func(input string) (string, error) {
+			return stripTags(input, false, decode), nil
+		}, nil
+	}
+
+	// Policies write HTML, decoded entities would turn text into tags
+	if decode {
+		return nil, fmt.Errorf("argument decode cannot be used with policy")
+	}
+
+	policy, ok := policies[name]
+	if !ok {
+		return nil, fmt.Errorf("argument policy=%s must be strict or ugc", name)
+	}
+
+	return func(input string) (string, error) {
+		return policy.Sanitize(input), nil
+	}, nil
+}
+
+// xmlRule builds xml or xml(decode=true)
+func xmlRule(args Args) (RuleFunc, error) {
+	if err := args.only(0, "decode"); err != nil {
+		return nil, err
+	}
+
+	decode, err := args.Bool("decode", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(input string) (string, error) {
+		return stripTags(input, true, decode), nil
+	}, nil
+}
+
+// urlRule builds url, url(www=keep) or url(removeWww=false), www is removed by default.
+// url(homograph=reject) fails with ErrHomograph for hosts mixing scripts.
+func urlRule(args Args) (RuleFunc, error) {
+	removeWww, rejectHomograph, err := hostArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(input string) (string, error) {
+		if rejectHomograph {
+			return RejectHomograph(URL(input, removeWww))
+		}
+
+		return URL(input, removeWww)
+	}, nil
+}
+
+// domainRule builds domain, domain(www=keep) or domain(removeWww=false), www is removed by default.
+// domain(homograph=reject) fails with ErrHomograph for hosts mixing scripts.
+func domainRule(args Args) (RuleFunc, error) {
+	removeWww, rejectHomograph, err := hostArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(input string) (string, error) {
+		if rejectHomograph {
+			return RejectHomograph(Domain(input, removeWww))
+		}
+
+		return Domain(input, removeWww)
+	}, nil
+}
+
+// hostArgs reads the arguments shared by url and domain, www or removeWww and homograph=allow|reject
+func hostArgs(args Args) (removeWww bool, rejectHomograph bool, err error) {
+	if err := args.only(0, "www", "removeWww", "homograph"); err != nil {
+		return false, false, err
+	}
+
+	removeWww, err = wwwArg(args)
+	if err != nil {
+		return false, false, err
+	}
+
+	switch homograph := args.String("homograph", "allow"); homograph {
+	case "allow":
+	case "reject":
+		rejectHomograph = true
+	default:
+		return false, false, fmt.Errorf("argument homograph=%s must be allow or reject", homograph)
+	}
+
+	return removeWww, rejectHomograph, nil
+}
+
+// wwwArg reads the www or removeWww argument shared by url and domain
+func wwwArg(args Args) (bool, error) {
+	removeWww, err := args.Bool("removeWww", true)
+	if err != nil {
+		return false, err
+	}
+
+	switch www := args.String("www", ""); www {
+	case "":
+	case "keep":
+		removeWww = false
+	case "remove":
+		removeWww = true
+	default:
+		return false, fmt.Errorf("argument www=%s must be keep or remove", www)
+	}
+
+	return removeWww, nil
+}
+
+// alphaRule builds alpha or alpha(spaces=false), white spaces are kept by default
+func alphaRule(args Args) (RuleFunc, error) {
+	spaces, err := spacesArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(input string) (string, error) {
+		return Alpha(input, spaces), nil
+	}, nil
+}
+
+// alphaNumericRule builds alphanumeric or alphanumeric(spaces=false), white spaces are kept by default
+func alphaNumericRule(args Args) (RuleFunc, error) {
+	spaces, err := spacesArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(input string) (string, error) {
+		return AlphaNumeric(input, spaces), nil
+	}, nil
+}
+
+// spacesArg reads the spaces argument shared by alpha and alphanumeric
+func spacesArg(args Args) (bool, error) {
+	if err := args.only(0, "spaces"); err != nil {
+		return false, err
+	}
+
+	return args.Bool("spaces", true)
+}
+
+// truncateRule builds truncate(n)
+func truncateRule(args Args) (RuleFunc, error) {
+	if err := args.only(1); err != nil {
+		return nil, err
+	}
+
+	length, err := args.Int(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("argument %d must not be negative", length)
+	}
+
+	return func(input string) (string, error) {
+		return Truncate(input, length), nil
+	}, nil
+}
@@ -0,0 +1,178 @@
+package sanitizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func trimRule(input string) (string, error) {
+	return strings.TrimSpace(input), nil
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register("test_trim", trimRule); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		fn      func(string) (string, error)
+		wantErr error
+	}{
+		{name: "Duplicate custom rule", rule: "test_trim", fn: trimRule, wantErr: ErrDuplicateRule},
+		{name: "Duplicate built-in rule", rule: xssField, fn: trimRule, wantErr: ErrDuplicateRule},
+		{name: "Empty rule name", rule: "", fn: trimRule},
+		{name: "Rule name with separator", rule: "trim,slug", fn: trimRule},
+		{name: "Missing rule function", rule: "test_nil", fn: nil},
+		{name: "Reserved rule name", rule: rejectOption, fn: trimRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Register(tt.rule, tt.fn)
+			if err == nil {
+				t.Fatalf("Register() expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Register() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	payload := &struct {
+		Name string `sanitize:"test_trim, alpha"`
+	}{
+		Name: "  John 3  ",
+	}
+
+	if err := Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	if payload.Name != "John " {
+		t.Errorf("Name sanitize error = %q", payload.Name)
+	}
+}
+
+func TestStructSanitizerRegister(t *testing.T) {
+	upper := func(input string) (string, error) {
+		return strings.ToUpper(input), nil
+	}
+
+	failing := func(input string) (string, error) {
+		return "", errors.New("rule failed")
+	}
+
+	st := &StructSanitizer{}
+	if err := st.Register("test_upper", upper); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := st.Register("test_failing", failing); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := st.Register("test_upper", upper); !errors.Is(err, ErrDuplicateRule) {
+		t.Errorf("Register() duplicate error = %v", err)
+	}
+	if err := st.Register(htmlField, upper); !errors.Is(err, ErrDuplicateRule) {
+		t.Errorf("Register() built-in duplicate error = %v", err)
+	}
+
+	// Registries given with WithRegistry cannot replace built-in rules either
+	registry := NewRegistry()
+	if err := registry.Register(htmlField, upper); !errors.Is(err, ErrDuplicateRule) {
+		t.Errorf("Registry.Register() built-in duplicate error = %v", err)
+	}
+	if err := registry.Register("test_registry_upper", upper); err != nil {
+		t.Fatalf("Registry.Register() error = %v", err)
+	}
+
+	// A rule added to the registry and later to the package keeps the package rule
+	registry.rules["test_registry_shadow"] = noArgs("test_registry_shadow", upper)
+	if err := Register("test_registry_shadow", func(input string) (string, error) { return "package", nil }); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	shadowed := &struct {
+		Name  string `sanitize:"html, test_registry_upper"`
+		Other string `sanitize:"test_registry_shadow"`
+	}{Name: "<b>john</b>", Other: "other"}
+
+	if err := New(WithRegistry(registry)).SanitizeStruct(shadowed); err != nil || shadowed.Name != "JOHN" || shadowed.Other != "package" {
+		t.Errorf("SanitizeStruct() with registry = %+v, error = %v", shadowed, err)
+	}
+
+	payload := &struct {
+		Name string `sanitize:"html, test_upper"`
+	}{
+		Name: "<b>john</b>",
+	}
+
+	if err := st.SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if payload.Name != "JOHN" {
+		t.Errorf("Name sanitize error = %q", payload.Name)
+	}
+
+	// Instance rules are not visible to other sanitizers
+	other := &struct {
+		Name string `sanitize:"test_upper"`
+	}{
+		Name: "john",
+	}
+
+	if err := Struct("sanitize", other); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	if other.Name != "john" {
+		t.Errorf("Name sanitize error = %q", other.Name)
+	}
+
+	failingPayload := &struct {
+		Name string `sanitize:"test_failing"`
+	}{
+		Name: "john",
+	}
+
+	if err := st.SanitizeStruct(failingPayload); err == nil {
+		t.Errorf("SanitizeStruct() expected error from failing rule")
+	}
+}
+
+func TestRegisterFactory(t *testing.T) {
+	repeat := func(args Args) (RuleFunc, error) {
+		count, err := args.Int(0)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(input string) (string, error) {
+			return strings.Repeat(input, count), nil
+		}, nil
+	}
+
+	st := &StructSanitizer{}
+	if err := st.RegisterFactory("test_repeat", repeat); err != nil {
+		t.Fatalf("RegisterFactory() error = %v", err)
+	}
+	if err := st.RegisterFactory(truncateField, repeat); !errors.Is(err, ErrDuplicateRule) {
+		t.Errorf("RegisterFactory() built-in duplicate error = %v", err)
+	}
+
+	payload := &struct {
+		Name string `sanitize:"test_repeat(3), truncate(5)"`
+	}{
+		Name: "ab",
+	}
+
+	if err := st.SanitizeStruct(payload); err != nil {
+		t.Fatalf("SanitizeStruct() error = %v", err)
+	}
+
+	if payload.Name != "ababa" {
+		t.Errorf("Name sanitize error = %q", payload.Name)
+	}
+}
@@ -0,0 +1,150 @@
+package sanitizer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Report lists what sanitizing a value would change, it can be serialized with encoding/json
+type Report struct {
+	Changes  []Change  `json:"changes"`
+	Failures []Failure `json:"failures,omitempty"`
+	Skipped  []string  `json:"skipped,omitempty"`
+}
+
+// Change is a value changed by a single rule, Before and After are empty and Redacted is set when the sanitizer redacts values
+type Change struct {
+	Path     string `json:"path"`
+	Rule     string `json:"rule"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// Failure is a field that would fail and keep its value
+type Failure struct {
+	Path  string `json:"path"`
+	Rule  string `json:"rule,omitempty"`
+	Error string `json:"error"`
+}
+
+// Changed reports whether sanitization would change anything
+func (r *Report) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// Report sanitizes a deep copy of v and returns every change it would make, v is left untouched.
+// The returned error is the one SanitizeStruct would return, its failures are also listed in the report.
+func (st *StructSanitizer) Report(v any) (*Report, error) {
+	report := &Report{}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return report, nil
+	}
+
+	w := st.newWalker()
+	w.copied = true
+	w.report = report
+	w.readClone(deepCopy(rv))
+
+	for _, field := range w.errs {
+		report.Failures = append(report.Failures, Failure{Path: field.Path, Rule: field.Rule, Error: field.Err.Error()})
+	}
+	report.Skipped = w.skipped
+
+	return report, w.err()
+}
+
+// reportChange records a value changed by a rule when the run builds a report
+func (w *walker) reportChange(path string, rule string, before string, after string) {
+	if w.report == nil || before == after {
+		return
+	}
+
+	change := Change{Path: path, Rule: rule, Redacted: w.redact}
+	if !w.redact {
+		change.Before, change.After = before, after
+	}
+
+	w.report.Changes = append(w.report.Changes, change)
+}
+
+// changeMark returns the number of changes recorded so far, the changes after it can be dropped with dropChanges
+func (w *walker) changeMark() int {
+	if w.report == nil {
+		return 0
+	}
+
+	return len(w.report.Changes)
+}
+
+// dropChanges removes the changes recorded after mark, a field failing on a later rule keeps its value
+func (w *walker) dropChanges(mark int) {
+	if w.report != nil {
+		w.report.Changes = w.report.Changes[:mark]
+	}
+}
+
+// takeChanges removes the changes recorded after mark and returns them, addChanges records them again
+func (w *walker) takeChanges(mark int) []Change {
+	if w.report == nil {
+		return nil
+	}
+
+	changes := append([]Change(nil), w.report.Changes[mark:]...)
+	w.dropChanges(mark)
+
+	return changes
+}
+
+// addChanges records changes returned by takeChanges
+func (w *walker) addChanges(changes []Change) {
+	if w.report != nil {
+		w.report.Changes = append(w.report.Changes, changes...)
+	}
+}
+
+// reportDiff records the strings a hook changed, before is a copy of after taken before the hook ran
+func (w *walker) reportDiff(path string, rule string, before reflect.Value, after reflect.Value, visited map[uintptr]bool) {
+	switch after.Kind() {
+	case reflect.String:
+		w.reportChange(path, rule, before.String(), after.String())
+
+	case reflect.Ptr, reflect.Interface:
+		if before.IsNil() || after.IsNil() {
+			return
+		}
+
+		if after.Kind() == reflect.Ptr {
+			if visited[after.Pointer()] {
+				return
+			}
+			visited[after.Pointer()] = true
+		}
+
+		if before.Elem().Type() == after.Elem().Type() {
+			w.reportDiff(path, rule, before.Elem(), after.Elem(), visited)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < after.NumField(); i++ {
+			w.reportDiff(fieldPath(path, after.Type().Field(i).Name), rule, before.Field(i), after.Field(i), visited)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < after.Len() && i < before.Len(); i++ {
+			w.reportDiff(fmt.Sprintf("%s[%d]", path, i), rule, before.Index(i), after.Index(i), visited)
+		}
+
+	case reflect.Map:
+		keys := after.MapKeys()
+		sortKeys(keys)
+
+		for _, key := range keys {
+			if old := before.MapIndex(key); old.IsValid() {
+				w.reportDiff(fmt.Sprintf("%s[%v]", path, key), rule, old, after.MapIndex(key), visited)
+			}
+		}
+	}
+}
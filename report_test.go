@@ -0,0 +1,146 @@
+package sanitizer
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type reportPayload struct {
+	Name    string            `sanitize:"html, alpha"`
+	Website string            `sanitize:"url"`
+	Link    string            `sanitize:"html, url"`
+	Tags    []string          `sanitize:"xss"`
+	Labels  map[string]string `sanitize:"keys=alpha, values=html"`
+	Total   money
+	Clean   string `sanitize:"html"`
+}
+
+func newReportPayload() *reportPayload {
+	return &reportPayload{
+		Name:    "<b>John 2</b>",
+		Website: "http://[::1]:namedport",
+		Link:    "<b>http://[::1]:namedport</b>",
+		Tags:    []string{"tag", `<script>alert("x")</script>tag`},
+		Labels:  map[string]string{"env1": "<i>prod</i>"},
+		Total:   "$ 10",
+		Clean:   "clean",
+	}
+}
+
+func TestReport(t *testing.T) {
+	payload := newReportPayload()
+
+	report, err := New().Report(payload)
+
+	var errs *Errors
+	if !errors.As(err, &errs) || errs.Fields[0].Path != "Website" {
+		t.Fatalf("Report() error = %v, want a Website failure", err)
+	}
+
+	want := &Report{
+		Changes: []Change{
+			{Path: "Name", Rule: "html", Before: "<b>John 2</b>", After: "John 2"},
+			{Path: "Name", Rule: "alpha", Before: "John 2", After: "John "},
+			{Path: "Tags[1]", Rule: "xss", Before: `<script>alert("x")</script>tag`, After: "tag"},
+			{Path: "Labels[env1]", Rule: "values=html", Before: "<i>prod</i>", After: "prod"},
+			{Path: "Labels[env1]", Rule: "keys=alpha", Before: "env1", After: "env"},
+			{Path: "Total", Rule: "Sanitize", Before: "$ 10", After: "10"},
+		},
+		// Link fails on url, the html change before it is not reported
+		Failures: []Failure{
+			{Path: "Website", Rule: "url", Error: errs.Fields[0].Err.Error()},
+			{Path: "Link", Rule: "url", Error: errs.Fields[1].Err.Error()},
+		},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Report() = %+v, want %+v", report, want)
+	}
+
+	if !reflect.DeepEqual(payload, newReportPayload()) {
+		t.Errorf("Report() changed the value to %+v", payload)
+	}
+
+	if !report.Changed() {
+		t.Errorf("Report.Changed() = false, want true")
+	}
+}
+
+func TestReportKeyCollision(t *testing.T) {
+	report, err := New().Report(&struct {
+		Labels map[string]string `sanitize:"keys=alpha, values=html"`
+	}{
+		Labels: map[string]string{"a1": "<b>x</b>", "a2": "y"},
+	})
+	if !errors.Is(err, ErrKeyCollision) {
+		t.Fatalf("Report() error = %v, want a key collision", err)
+	}
+
+	// Colliding keys are kept, only the value changes are reported
+	want := []Change{{Path: "Labels[a1]", Rule: "values=html", Before: "<b>x</b>", After: "x"}}
+	if !reflect.DeepEqual(report.Changes, want) || len(report.Failures) != 1 {
+		t.Errorf("Report() = %+v, want changes %+v and one failure", report, want)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	payload := struct {
+		Name  string `sanitize:"html"`
+		Empty string `sanitize:"html"`
+	}{Name: "<b>name</b>", Empty: "<b></b>"}
+
+	tests := []struct {
+		name   string
+		redact bool
+		want   string
+	}{
+		{
+			name: "Values",
+			want: `{"changes":[{"path":"Name","rule":"html","before":"\u003cb\u003ename\u003c/b\u003e","after":"name"},` +
+				`{"path":"Empty","rule":"html","before":"\u003cb\u003e\u003c/b\u003e","after":""}]}`,
+		},
+		{
+			name:   "Redacted",
+			redact: true,
+			want: `{"changes":[{"path":"Name","rule":"html","before":"","after":"","redacted":true},` +
+				`{"path":"Empty","rule":"html","before":"","after":"","redacted":true}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := New(WithRedact(tt.redact)).Report(payload)
+			if err != nil {
+				t.Fatalf("Report() error = %v", err)
+			}
+
+			got, err := json.Marshal(report)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportUnchanged(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "Nil", v: nil},
+		{name: "Clean struct", v: &Address{City: "City"}},
+		{name: "Untagged strings", v: map[string]string{"key": "<b>value</b>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := New().Report(tt.v)
+			if err != nil || report.Changed() {
+				t.Errorf("Report() = %+v, %v, want no changes", report, err)
+			}
+		})
+	}
+}
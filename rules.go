@@ -0,0 +1,347 @@
+package sanitizer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rule is a single parsed entry of a struct tag, e.g. alpha(spaces=false) or keys=alphanumeric
+type rule struct {
+	option string
+	name   string
+	args   Args
+}
+
+// Args holds the arguments given to a rule in a struct tag, e.g. truncate(64) or url(www=keep)
+type Args struct {
+	Positional []string
+	Named      map[string]string
+}
+
+// String writes the rule back as it appears in a tag, e.g. keys=alpha(spaces=false)
+func (r rule) String() string {
+	var b strings.Builder
+
+	if r.option != "" {
+		b.WriteString(r.option + "=")
+	}
+
+	b.WriteString(r.name)
+
+	if !r.args.Empty() {
+		args := append([]string(nil), r.args.Positional...)
+
+		keys := make([]string, 0, len(r.args.Named))
+		for key := range r.args.Named {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			args = append(args, key+"="+r.args.Named[key])
+		}
+
+		b.WriteString("(" + strings.Join(args, ruleSeparator) + ")")
+	}
+
+	return b.String()
+}
+
+// Empty reports whether no arguments were given
+func (a Args) Empty() bool {
+	return len(a.Positional) == 0 && len(a.Named) == 0
+}
+
+// String returns the named argument or def when it is missing
+func (a Args) String(key string, def string) string {
+	if value, ok := a.Named[key]; ok {
+		return value
+	}
+
+	return def
+}
+
+// Bool returns the named argument as a boolean or def when it is missing
+func (a Args) Bool(key string, def bool) (bool, error) {
+	value, ok := a.Named[key]
+	if !ok {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("argument %s=%s is not a boolean", key, value)
+	}
+
+	return b, nil
+}
+
+// Int returns the positional argument at index i as an integer
+func (a Args) Int(i int) (int, error) {
+	if i >= len(a.Positional) {
+		return 0, fmt.Errorf("missing argument %d", i+1)
+	}
+
+	n, err := strconv.Atoi(a.Positional[i])
+	if err != nil {
+		return 0, fmt.Errorf("argument %s is not an integer", a.Positional[i])
+	}
+
+	return n, nil
+}
+
+// only makes sure no other named or positional arguments than the given ones were used
+func (a Args) only(positional int, keys ...string) error {
+	if len(a.Positional) > positional {
+		return fmt.Errorf("expected at most %d positional arguments, got %d", positional, len(a.Positional))
+	}
+
+	for key := range a.Named {
+		known := false
+		for _, k := range keys {
+			if key == k {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return fmt.Errorf("unknown argument %s", key)
+		}
+	}
+
+	return nil
+}
+
+// Rule is a parsed rule of a struct tag, e.g. alpha(spaces=false) or keys=alphanumeric
+type Rule struct {
+	Option string // keys or values, empty for rules applied to the value
+	Name   string
+	Args   Args
+}
+
+// String writes the rule back as it appears in a tag
+func (r Rule) String() string {
+	return rule{option: r.Option, name: r.Name, args: r.Args}.String()
+}
+
+// ParseTag parses a struct tag value into its ordered rules, code generators use it to read tags,
+// the reject flag is returned as a rule named reject
+func ParseTag(tagValue string) ([]Rule, error) {
+	if _, err := parseTag(tagValue); err != nil {
+		return nil, err
+	}
+
+	rules, err := parseRules(tagValue)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]Rule, len(rules))
+	for i, r := range rules {
+		parsed[i] = Rule{Option: r.option, Name: r.name, Args: r.args}
+	}
+
+	return parsed, nil
+}
+
+// tagRules holds the parsed rules of a struct tag
+type tagRules struct {
+	values []rule // applied to strings, slice elements and map values
+	keys   []rule // applied to map keys, set with keys=rule
+	reject bool   // values the rules would change fail instead of being rewritten
+}
+
+// empty reports whether the tag has no rules at all
+func (tr tagRules) empty() bool {
+	return len(tr.values) == 0 && len(tr.keys) == 0
+}
+
+// parseTag parses a struct tag like "xss, keys=alphanumeric, values=trim, reject" into its rules
+func parseTag(tagValue string) (tagRules, error) {
+	var tr tagRules
+
+	rules, err := parseRules(tagValue)
+	if err != nil {
+		return tr, err
+	}
+
+	for _, r := range rules {
+		if r.name == rejectOption {
+			if r.option != "" || !r.args.Empty() {
+				return tr, fmt.Errorf("malformed rule %q: %s takes no option or arguments", r.String(), rejectOption)
+			}
+
+			tr.reject = true
+			continue
+		}
+
+		switch r.option {
+		case "":
+			tr.values = append(tr.values, r)
+		case keysOption:
+			tr.keys = append(tr.keys, r)
+		case valuesOption:
+			tr.values = append(tr.values, r)
+		default:
+			return tr, fmt.Errorf("unknown tag option %s", r.option)
+		}
+	}
+
+	return tr, nil
+}
+
+// parseRules splits a tag value like "xss, alpha(spaces=false)" into its ordered rules
+func parseRules(tagValue string) ([]rule, error) {
+	var rules []rule
+
+	tokens, err := splitTopLevel(tagValue)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		r, err := parseRule(token)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// parseRule parses a single rule with its optional tag option and argument list
+func parseRule(token string) (rule, error) {
+	// Tag options like keys=alphanumeric, the = has to come before any argument list
+	if eq := strings.IndexByte(token, '='); eq >= 0 {
+		if open := strings.IndexByte(token, '('); open < 0 || eq < open {
+			option := strings.TrimSpace(token[:eq])
+			if option == "" {
+				return rule{}, fmt.Errorf("malformed rule %q: missing option name", token)
+			}
+
+			r, err := parseRule(strings.TrimSpace(token[eq+1:]))
+			if err != nil {
+				return rule{}, err
+			}
+
+			if r.option != "" || r.name == "" {
+				return rule{}, fmt.Errorf("malformed rule %q", token)
+			}
+
+			r.option = option
+			return r, nil
+		}
+	}
+
+	open := strings.IndexByte(token, '(')
+	if open < 0 {
+		if token == "" || strings.ContainsAny(token, ") ") {
+			return rule{}, fmt.Errorf("malformed rule %q", token)
+		}
+
+		return rule{name: token}, nil
+	}
+
+	name := strings.TrimSpace(token[:open])
+	if name == "" {
+		return rule{}, fmt.Errorf("malformed rule %q: missing name", token)
+	}
+
+	if !strings.HasSuffix(token, ")") {
+		return rule{}, fmt.Errorf("malformed rule %q: missing closing parenthesis", token)
+	}
+
+	args, err := parseArgs(token[open+1 : len(token)-1])
+	if err != nil {
+		return rule{}, fmt.Errorf("malformed rule %q: %v", token, err)
+	}
+
+	return rule{name: name, args: args}, nil
+}
+
+// parseArgs parses "64" or "spaces=false, www=keep" into positional and named arguments
+func parseArgs(list string) (Args, error) {
+	var args Args
+
+	if strings.TrimSpace(list) == "" {
+		return args, nil
+	}
+
+	for _, arg := range strings.Split(list, ruleSeparator) {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			return args, fmt.Errorf("empty argument")
+		}
+
+		if strings.ContainsAny(arg, "()") {
+			return args, fmt.Errorf("unexpected parenthesis in argument %q", arg)
+		}
+
+		key, value, named := strings.Cut(arg, "=")
+		if !named {
+			if len(args.Named) > 0 {
+				return args, fmt.Errorf("positional argument %q after named arguments", arg)
+			}
+
+			args.Positional = append(args.Positional, arg)
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key == "" || value == "" {
+			return args, fmt.Errorf("malformed argument %q", arg)
+		}
+
+		if args.Named == nil {
+			args.Named = make(map[string]string)
+		}
+
+		if _, ok := args.Named[key]; ok {
+			return args, fmt.Errorf("duplicate argument %s", key)
+		}
+
+		args.Named[key] = value
+	}
+
+	return args, nil
+}
+
+// splitTopLevel splits on rule separators that are not inside parentheses
+func splitTopLevel(tagValue string) ([]string, error) {
+	var tokens []string
+
+	depth, start := 0, 0
+	for i := 0; i < len(tagValue); i++ {
+		switch tagValue[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("malformed tag %q: unexpected closing parenthesis", tagValue)
+			}
+		case ruleSeparator[0]:
+			if depth == 0 {
+				tokens = append(tokens, tagValue[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("malformed tag %q: missing closing parenthesis", tagValue)
+	}
+
+	return append(tokens, tagValue[start:]), nil
+}
@@ -0,0 +1,313 @@
+package sanitizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		tagValue string
+		want     []rule
+		wantErr  bool
+	}{
+		{name: "Single rule", tagValue: "xss", want: []rule{{name: "xss"}}},
+		{name: "Chained rules", tagValue: "xss,alpha", want: []rule{{name: "xss"}, {name: "alpha"}}},
+		{name: "Chained rules with spaces", tagValue: " xss ,  alpha ", want: []rule{{name: "xss"}, {name: "alpha"}}},
+		{name: "Empty rules are ignored", tagValue: "xss,,alpha,", want: []rule{{name: "xss"}, {name: "alpha"}}},
+		{name: "Empty tag", tagValue: "", want: nil},
+		{
+			name:     "Positional argument",
+			tagValue: "truncate(64)",
+			want:     []rule{{name: "truncate", args: Args{Positional: []string{"64"}}}},
+		},
+		{
+			name:     "Named arguments between chained rules",
+			tagValue: "xss, alpha(spaces=false), url( www = keep )",
+			want: []rule{
+				{name: "xss"},
+				{name: "alpha", args: Args{Named: map[string]string{"spaces": "false"}}},
+				{name: "url", args: Args{Named: map[string]string{"www": "keep"}}},
+			},
+		},
+		{
+			name:     "Several arguments",
+			tagValue: "custom(1, 2, mode=fast)",
+			want: []rule{
+				{name: "custom", args: Args{Positional: []string{"1", "2"}, Named: map[string]string{"mode": "fast"}}},
+			},
+		},
+		{name: "Empty argument list", tagValue: "xss()", want: []rule{{name: "xss"}}},
+		{name: "Missing closing parenthesis", tagValue: "alpha(spaces=false", wantErr: true},
+		{name: "Unexpected closing parenthesis", tagValue: "alpha)", wantErr: true},
+		{name: "Text after arguments", tagValue: "alpha(spaces=false)x", wantErr: true},
+		{name: "Missing rule name", tagValue: "(64)", wantErr: true},
+		{name: "Nested parenthesis", tagValue: "alpha(spaces=(false))", wantErr: true},
+		{name: "Empty argument", tagValue: "custom(1,,2)", wantErr: true},
+		{name: "Missing argument value", tagValue: "alpha(spaces=)", wantErr: true},
+		{name: "Duplicate argument", tagValue: "alpha(spaces=true, spaces=false)", wantErr: true},
+		{name: "Positional after named argument", tagValue: "custom(mode=fast, 1)", wantErr: true},
+		{name: "Space inside rule name", tagValue: "xss alpha", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRules(tt.tagValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructRuleArguments(t *testing.T) {
+	payload := &struct {
+		Alpha        string `sanitize:"alpha(spaces=false)"`
+		AlphaSpaces  string `sanitize:"alpha(spaces=true)"`
+		AlphaNumeric string `sanitize:"alphanumeric(spaces=false)"`
+		KeepWww      string `sanitize:"url(www=keep)"`
+		RemoveWww    string `sanitize:"url(removeWww=true)"`
+		Domain       string `sanitize:"domain"`
+		DomainWww    string `sanitize:"domain(www=keep)"`
+		DomainIDN    string `sanitize:"domain(homograph=reject)"`
+		Truncate     string `sanitize:"xss, truncate(5)"`
+		HTMLText     string `sanitize:"html(decode=true)"`
+		XMLText      string `sanitize:"xml(decode=true)"`
+	}{
+		Alpha:        "Just letters 123",
+		AlphaSpaces:  "Just letters 123",
+		AlphaNumeric: "Letters and 123 !@#",
+		KeepWww:      "www.example.com",
+		RemoveWww:    "www.example.com",
+		Domain:       "https://www.Example.com:8080/path",
+		DomainWww:    "https://www.Example.com:8080/path",
+		DomainIDN:    "www.münchen.de",
+		Truncate:     "<b>Hello</b> world",
+		HTMLText:     "<p>Tom &amp; Jerry</p>",
+		XMLText:      "<note>a &lt; b</note>",
+	}
+
+	if err := Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "Alpha", got: payload.Alpha, want: "Justletters"},
+		{name: "AlphaSpaces", got: payload.AlphaSpaces, want: "Just letters "},
+		{name: "AlphaNumeric", got: payload.AlphaNumeric, want: "Lettersand123"},
+		{name: "KeepWww", got: payload.KeepWww, want: "https://www.example.com"},
+		{name: "RemoveWww", got: payload.RemoveWww, want: "https://example.com"},
+		{name: "Domain", got: payload.Domain, want: "example.com"},
+		{name: "DomainWww", got: payload.DomainWww, want: "www.example.com"},
+		{name: "DomainIDN", got: payload.DomainIDN, want: "xn--mnchen-3ya.de"},
+		{name: "Truncate", got: payload.Truncate, want: "Hello"},
+		{name: "HTMLText", got: payload.HTMLText, want: "Tom & Jerry"},
+		{name: "XMLText", got: payload.XMLText, want: "a < b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s sanitize error = %q, want %q", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructRuleArgumentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    string
+	}{
+		{
+			name: "Malformed tag",
+			payload: &struct {
+				Name string `sanitize:"alpha(spaces=false"`
+			}{Name: "name"},
+			want: "field Name",
+		},
+		{
+			name: "Invalid boolean",
+			payload: &struct {
+				Name string `sanitize:"alpha(spaces=maybe)"`
+			}{Name: "name"},
+			want: "field Name: rule alpha",
+		},
+		{
+			name: "Unknown argument",
+			payload: &struct {
+				Website string `sanitize:"url(scheme=http)"`
+			}{Website: "example.com"},
+			want: "field Website: rule url(scheme=http): unknown argument scheme",
+		},
+		{
+			name: "Invalid www value",
+			payload: &struct {
+				Website string `sanitize:"url(www=drop)"`
+			}{Website: "example.com"},
+			want: "field Website: rule url",
+		},
+		{
+			name: "Invalid homograph value",
+			payload: &struct {
+				Website string `sanitize:"domain(homograph=warn)"`
+			}{Website: "example.com"},
+			want: "argument homograph=warn must be allow or reject",
+		},
+		{
+			name: "Homograph domain",
+			payload: &struct {
+				Website string `sanitize:"domain(homograph=reject)"`
+			}{Website: "p" + string(rune(0x430)) + "ypal.com"},
+			want: "field Website: rule domain(homograph=reject): domain mixes scripts: xn--pypal-4ve.com",
+		},
+		{
+			name: "Homograph URL",
+			payload: &struct {
+				Website string `sanitize:"url(www=keep, homograph=reject)"`
+			}{Website: "https://www.p" + string(rune(0x430)) + "ypal.com/login"},
+			want: "domain mixes scripts: www.xn--pypal-4ve.com",
+		},
+		{
+			name: "Invalid domain",
+			payload: &struct {
+				Website string `sanitize:"domain"`
+			}{Website: "not a domain"},
+			want: `field Website: rule domain: invalid domain`,
+		},
+		{
+			name: "Missing truncate length",
+			payload: &struct {
+				Name string `sanitize:"truncate"`
+			}{Name: "name"},
+			want: "field Name: rule truncate",
+		},
+		{
+			name: "Negative truncate length",
+			payload: &struct {
+				Name string `sanitize:"truncate(-1)"`
+			}{Name: "name"},
+			want: "field Name: rule truncate",
+		},
+		{
+			name: "Decoding with a policy",
+			payload: &struct {
+				Comment string `sanitize:"html(policy=ugc, decode=true)"`
+			}{Comment: "comment"},
+			want: "field Comment: rule html(decode=true,policy=ugc): argument decode cannot be used with policy",
+		},
+		{
+			name: "Arguments on a rule without arguments",
+			payload: &struct {
+				Tags []string `sanitize:"xss(strict=true)"`
+			}{Tags: []string{"tag"}},
+			want: "field Tags[0]: rule xss",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct("sanitize", tt.payload)
+			if err == nil {
+				t.Fatalf("Struct() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Struct() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		tagValue string
+		want     tagRules
+		wantErr  bool
+	}{
+		{
+			name:     "Only value rules",
+			tagValue: "xss, alpha",
+			want:     tagRules{values: []rule{{name: "xss"}, {name: "alpha"}}},
+		},
+		{
+			name:     "Keys and values options",
+			tagValue: "keys=alphanumeric, values=xss",
+			want: tagRules{
+				values: []rule{{option: valuesOption, name: "xss"}},
+				keys:   []rule{{option: keysOption, name: "alphanumeric"}},
+			},
+		},
+		{
+			name:     "Options with arguments",
+			tagValue: "html, keys = alpha(spaces=false), keys=truncate(3)",
+			want: tagRules{
+				values: []rule{{name: "html"}},
+				keys: []rule{
+					{option: keysOption, name: "alpha", args: Args{Named: map[string]string{"spaces": "false"}}},
+					{option: keysOption, name: "truncate", args: Args{Positional: []string{"3"}}},
+				},
+			},
+		},
+		{
+			name:     "Reject flag",
+			tagValue: "xss, reject, keys=alpha",
+			want: tagRules{
+				values: []rule{{name: "xss"}},
+				keys:   []rule{{option: keysOption, name: "alpha"}},
+				reject: true,
+			},
+		},
+		{name: "Reject with an option", tagValue: "keys=reject", wantErr: true},
+		{name: "Reject with arguments", tagValue: "xss, reject(true)", wantErr: true},
+		{name: "Unknown option", tagValue: "elements=xss", wantErr: true},
+		{name: "Missing option rule", tagValue: "keys=", wantErr: true},
+		{name: "Missing option name", tagValue: "=xss", wantErr: true},
+		{name: "Nested options", tagValue: "keys=values=xss", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTag(tt.tagValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTag() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagExported(t *testing.T) {
+	got, err := ParseTag("xss, keys=alpha(spaces=false), truncate(8)")
+	if err != nil {
+		t.Fatalf("ParseTag() error = %v", err)
+	}
+
+	want := []Rule{
+		{Name: "xss"},
+		{Option: keysOption, Name: "alpha", Args: Args{Named: map[string]string{"spaces": "false"}}},
+		{Name: "truncate", Args: Args{Positional: []string{"8"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTag() = %+v, want %+v", got, want)
+	}
+
+	if got[1].String() != "keys=alpha(spaces=false)" {
+		t.Errorf("Rule.String() = %s", got[1].String())
+	}
+
+	if _, err := ParseTag("keys=values=xss"); err == nil {
+		t.Errorf("ParseTag() expected error for nested options")
+	}
+}
@@ -0,0 +1,135 @@
+package sanitizer
+
+import "strings"
+
+// dangerousElements run code, load other documents or change how the page is read, Scripts removes them with their content
+var dangerousElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"frame":    true,
+	"frameset": true,
+	"object":   true,
+	"embed":    true,
+	"applet":   true,
+	"svg":      true,
+	"math":     true,
+	"base":     true,
+	"noembed":  true,
+}
+
+// emptyElements have no content or closing tag, only the tag itself is removed.
+// embed is empty too but it is often written with a closing tag, so its content is removed with it
+var emptyElements = map[string]bool{
+	"base":  true,
+	"frame": true,
+	"meta":  true,
+}
+
+// dangerousTag reports whether a start tag opens a dangerous element, meta is only dangerous with http-equiv
+// since it can redirect the page or set cookies
+func dangerousTag(t token) bool {
+	if t.name != "meta" {
+		return dangerousElements[t.name]
+	}
+
+	for _, a := range t.attrs {
+		if a.name == "http-equiv" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scriptSpans returns the byte ranges of the dangerous elements in input with their content, ordered by offset.
+// Elements that are never closed run to the end of the input and nested elements of the same name are matched.
+func scriptSpans(input string) [][2]int {
+	var spans [][2]int
+
+	var (
+		open  string // dangerous element being removed
+		depth int    // nesting of open inside itself
+		start int    // offset of the tag that opened it
+	)
+
+	z := newTokenizer(input, false)
+	for {
+		offset := z.pos
+		t, ok := z.next()
+		if !ok {
+			break
+		}
+
+		if open != "" {
+			switch {
+			case t.name != open:
+			case t.typ == startTagToken:
+				depth++
+			case t.typ == endTagToken:
+				depth--
+			}
+
+			if depth == 0 {
+				spans = append(spans, [2]int{start, z.pos})
+				open = ""
+			}
+			continue
+		}
+
+		switch t.typ {
+		case startTagToken, selfClosingTagToken:
+			if !dangerousTag(t) {
+				continue
+			}
+
+			// Self closing raw text elements like <script/> still hold the text after them
+			if t.eof || emptyElements[t.name] || (t.typ == selfClosingTagToken && !rawTextElements[t.name]) {
+				spans = append(spans, [2]int{offset, z.pos})
+				continue
+			}
+
+			open, depth, start = t.name, 1, offset
+
+		case endTagToken:
+			// Stray closing tags are removed too, e.g. </embed>
+			if dangerousElements[t.name] {
+				spans = append(spans, [2]int{offset, z.pos})
+			}
+		}
+	}
+
+	if open != "" {
+		spans = append(spans, [2]int{start, len(input)})
+	}
+
+	return spans
+}
+
+// removeScripts removes the dangerous elements found by scriptSpans once
+func removeScripts(input string) string {
+	return removeSpans(input, scriptSpans(input))
+}
+
+// removeSpans removes the byte ranges from input, spans are ordered by offset and may overlap
+func removeSpans(input string, spans [][2]int) string {
+	if len(spans) == 0 {
+		return input
+	}
+
+	var b strings.Builder
+	b.Grow(len(input))
+
+	last := 0
+	for _, span := range spans {
+		if span[0] > last {
+			b.WriteString(input[last:span[0]])
+		}
+		if span[1] > last {
+			last = span[1]
+		}
+	}
+	b.WriteString(input[last:])
+
+	return b.String()
+}
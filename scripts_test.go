@@ -0,0 +1,74 @@
+package sanitizer
+
+import "testing"
+
+// scriptsCorpus holds payloads Scripts has to remove and markup it has to keep
+var scriptsCorpus = []struct {
+	name  string
+	input string
+	want  string
+}{
+	{name: "No markup", input: "3 < 4 and 5 > 2", want: "3 < 4 and 5 > 2"},
+	{name: "Safe markup is kept", input: `<p class="x">Hello <b>world</b></p>`, want: `<p class="x">Hello <b>world</b></p>`},
+	{name: "Script block", input: `a<script>alert(1)</script>b`, want: "ab"},
+	{name: "Upper case script", input: `a<SCRIPT type="text/javascript">alert(1)</SCRIPT >b`, want: "ab"},
+	{
+		name: "Script spanning lines",
+		input: `before<script>
+  var x = 1;
+  alert(x);
+</script>after`,
+		want: "beforeafter",
+	},
+	{name: "Text between scripts is kept", input: `<script>a()</script>keep me<script>b()</script>`, want: "keep me"},
+	{name: "Closing tag inside a string", input: `<script>var s = "</b>";</script>ok`, want: "ok"},
+	{name: "Unclosed script", input: `Hi <script>alert(1)`, want: "Hi "},
+	{name: "Unterminated script tag", input: `Hi <script src="x.js"`, want: "Hi "},
+	{name: "Self closing script keeps reading", input: `a<script/>alert(1)</script>b`, want: "ab"},
+	{name: "Script with attributes", input: `a<script src="https://evil.example/x.js" async></script>b`, want: "ab"},
+	{name: "Style", input: `a<style>body { background: url(javascript:x) }</style>b`, want: "ab"},
+	{name: "Iframe", input: `a<iframe src="https://evil.example"></iframe>b`, want: "ab"},
+	{name: "Object and embed", input: `a<object data="x.swf"><embed src="x.swf"></embed></object>b`, want: "ab"},
+	{name: "Applet", input: `a<applet code="X.class"><param name="x" value="y"></applet>b`, want: "ab"},
+	{name: "Svg", input: `a<svg onload="alert(1)"><circle r="1"/></svg>b`, want: "ab"},
+	{name: "Nested svg", input: `a<svg><svg><script>alert(1)</script></svg>hidden</svg>b`, want: "ab"},
+	{name: "Self closing svg", input: `a<svg width="1" />b`, want: "ab"},
+	{name: "Svg with a slash before attributes", input: `a<svg/onload=alert(1)>b`, want: "a"},
+	{name: "Unclosed svg", input: `a<svg><g><text>x</text>`, want: "a"},
+	{name: "Math", input: `a<math><mtext><img src=x onerror=alert(1)></mtext></math>b`, want: "ab"},
+	{name: "Frameset", input: `a<frameset><frame src="x.html"><frame src="y.html"></frameset>b`, want: "ab"},
+	{name: "Frame", input: `a<frame src="x.html">b`, want: "ab"},
+	{name: "Base", input: `<base href="https://evil.example/">a`, want: "a"},
+	{name: "Meta refresh", input: `<meta http-equiv="refresh" content="0;url=javascript:alert(1)">a`, want: "a"},
+	{name: "Meta charset is kept", input: `<meta charset="utf-8">a`, want: `<meta charset="utf-8">a`},
+	{name: "Stray closing tags", input: `a</script>b</svg>c`, want: "abc"},
+	{name: "Split tag is removed again", input: `<scr<script></script>ipt>alert(1)</script>`, want: `<scr<script>ipt>alert(1)`},
+	{name: "Rejoined script", input: `<<script></script>script>alert(1)<<script></script>/script>`, want: ""},
+	{name: "Script inside a comment is kept", input: `<!-- <script>x</script> -->a`, want: `<!-- <script>x</script> -->a`},
+	{name: "Script in an attribute is kept", input: `<a title="<script>x</script>">a</a>`, want: `<a title="<script>x</script>">a</a>`},
+}
+
+func TestScriptsCorpus(t *testing.T) {
+	for _, tt := range scriptsCorpus {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Scripts(tt.input); got != tt.want {
+				t.Errorf("Scripts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectScriptsCorpus(t *testing.T) {
+	for _, tt := range scriptsCorpus {
+		t.Run(tt.name, func(t *testing.T) {
+			found := false
+			for _, f := range Detect(tt.input) {
+				found = found || f.Category == CategoryScriptTag
+			}
+
+			if changed := Scripts(tt.input) != tt.input; found != changed {
+				t.Errorf("Detect() found script tags = %v, Scripts() changed the input = %v", found, changed)
+			}
+		})
+	}
+}
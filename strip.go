@@ -0,0 +1,88 @@
+package sanitizer
+
+import (
+	"html"
+	"strings"
+)
+
+// hiddenElements hold no visible text, their content is removed with their tags
+var hiddenElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"noembed":  true,
+	"noframes": true,
+}
+
+// stripTags removes tags, comments and declarations and keeps the text as written, decode turns entities into plain text
+func stripTags(input string, xml bool, decode bool) string {
+	// Decoded text is plain text, a stripped < joining the text after it does not matter there
+	if decode {
+		return stripOnce(input, xml, true)
+	}
+
+	// Removing a tag can join a stray < with the text after it into a new tag, e.g. <<b>img onerror=x>
+	for {
+		stripped := stripOnce(input, xml, false)
+		if stripped == input {
+			return stripped
+		}
+		input = stripped
+	}
+}
+
+// stripOnce removes the tags, comments and declarations of input once
+func stripOnce(input string, xml bool, decode bool) string {
+	if strings.IndexByte(input, '<') < 0 {
+		if decode {
+			return html.UnescapeString(input)
+		}
+
+		return input
+	}
+
+	var b strings.Builder
+	b.Grow(len(input))
+
+	z := newTokenizer(input, xml)
+	for {
+		t, ok := z.next()
+		if !ok {
+			break
+		}
+
+		switch t.typ {
+		case textToken:
+			writeText(&b, t.raw, decode)
+
+		case commentToken:
+			// Unterminated declarations like <?@ cannot become tags, they are text like before the tokenizer
+			if t.eof {
+				writeText(&b, t.raw, decode)
+			}
+
+		case cdataToken:
+			// CDATA sections are text in XML and bogus comments in HTML
+			if xml {
+				b.WriteString(t.raw)
+			}
+
+		case rawTextToken:
+			// Text of elements like title and textarea may hold tags that are read as text
+			if !hiddenElements[t.name] {
+				b.WriteString(stripOnce(t.raw, xml, decode))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// writeText writes text as written or with its entities decoded
+func writeText(b *strings.Builder, text string, decode bool) {
+	if decode {
+		text = html.UnescapeString(text)
+	}
+
+	b.WriteString(text)
+}
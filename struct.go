@@ -1,24 +1,62 @@
 package sanitizer
 
 import (
-	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"sort"
+	"sync"
 )
 
+// StructSanitizer sanitizes struct fields based on their tags, create one with New to reuse it
 type StructSanitizer struct {
-	verbose bool
-	tagName string
+	verbose    bool
+	redact     bool
+	strict     bool
+	reject     bool
+	tagName    string
+	maxDepth   int
+	log        *slog.Logger
+	registry   *Registry
+	unsettable UnsettablePolicy
+	plans      sync.Map // reflect.Type to *structPlan
 }
 
-// Struct Sanitizes the given struct
-func Struct(tagName string, any interface{}) error {
-	ret := StructSanitizer{
-		verbose: false,
-		tagName: tagName,
+// walker holds the state of a single sanitization run
+type walker struct {
+	*StructSanitizer
+	log     *slog.Logger
+	depth   int
+	errs    []*FieldError
+	skipped []string
+	copied  bool    // the value is a deep copy, pointers behind unexported fields still point to the original
+	report  *Report // changes are recorded when the run builds a report
+	visited map[visitKey]bool
+	tooDeep bool // ErrMaxDepth was recorded, it is reported once per run
+}
+
+// visitKey identifies a value reached through a pointer or a map, the type tells apart a struct from its first field
+type visitKey struct {
+	t reflect.Type
+	p uintptr
+}
+
+// defaultSanitizers holds a sanitizer per tag name for Struct, so their plans are reused
+var defaultSanitizers sync.Map
+
+// defaultSanitizer returns the shared sanitizer of a tag name
+func defaultSanitizer(tagName string) *StructSanitizer {
+	ret, ok := defaultSanitizers.Load(tagName)
+	if !ok {
+		ret, _ = defaultSanitizers.LoadOrStore(tagName, New(WithTagName(tagName)))
 	}
 
-	err := ret.SanitizeStruct(any)
+	return ret.(*StructSanitizer)
+}
+
+// Struct Sanitizes the given struct
+func Struct(tagName string, any interface{}) error {
+	err := defaultSanitizer(tagName).SanitizeStruct(any)
 	if err != nil {
 		return err
 	}
@@ -26,18 +64,21 @@ func Struct(tagName string, any interface{}) error {
 	return nil
 }
 
-// SanitizeStruct Checks the given interface
+// SanitizeStruct Checks the given interface, every field failure is returned in an *Errors
 func (st *StructSanitizer) SanitizeStruct(any interface{}) error {
 	// read value of interface
 	v := reflect.ValueOf(any)
 
 	// check if struct is a pointer
-	if v.Kind() != reflect.Pointer {
-		return errors.New("struct needs to be a pointer")
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ErrNotPointer
 	}
 
 	// pointer value
 	value := v.Elem()
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("%w to a struct", ErrNotPointer)
+	}
 
 	//Check number of fields
 	t := value.Type()
@@ -45,208 +86,397 @@ func (st *StructSanitizer) SanitizeStruct(any interface{}) error {
 
 	// Empty struct
 	if numValues == 0 {
-		return errors.New("struct is empty")
+		return ErrEmptyStruct
 	}
 
-	// Read struct
-	err := st.readStruct(value)
-	if err != nil {
-		return err
+	// Read struct, fields pointing back to it are not walked again
+	w := st.newWalker()
+	w.visit(v)
+	w.readRoot(value)
+
+	return w.err()
+}
+
+// newWalker starts a sanitization run
+func (st *StructSanitizer) newWalker() *walker {
+	return &walker{StructSanitizer: st, log: st.logger()}
+}
+
+// err returns the failures of the run, or the fields skipped with UnsettableSkip
+func (w *walker) err() error {
+	if len(w.errs) > 0 {
+		return &Errors{Fields: w.errs}
+	}
+
+	if len(w.skipped) > 0 {
+		return &SkippedFieldsError{Paths: w.skipped}
 	}
 
 	return nil
 }
 
-// readStruct Recursive Read struct fields
-func (st *StructSanitizer) readStruct(v reflect.Value) error {
-	for i := 0; i < v.NumField(); i++ {
-		// Get the field
-		field := v.Type().Field(i)
-		tag := field.Tag
+// readRoot sanitizes the struct given to the sanitizer, Sanitize and SanitizeWith are not called on it so they can call SanitizeStruct
+func (w *walker) readRoot(v reflect.Value) {
+	if hooks := typeHooks(v.Type()) &^ selfHooks; hooks != 0 {
+		w.readHooked(v, "", hooks, func() { w.readStruct(v, "") })
+		return
+	}
 
-		// Get the tag value
-		tagValue := tag.Get(st.tagName)
+	w.readStruct(v, "")
+}
 
-		// Check if field is a Pointer
-		if v.Field(i).Kind() == reflect.Ptr {
-			element := v.Field(i).Elem()
+// fail records a field failure and lets the run continue with the next field
+func (w *walker) fail(path string, rule string, err error) {
+	w.errs = append(w.errs, &FieldError{Path: path, Rule: rule, Err: err})
+}
 
-			if !v.Field(i).IsNil() && element.Kind() == reflect.Struct {
-				if err := st.readStruct(element); err != nil {
-					return err
-				}
-			}
+// readStruct Read struct fields following the cached plan of its type, path is the field path of the struct itself
+func (w *walker) readStruct(v reflect.Value, path string) {
+	for _, field := range w.plan(v.Type()).fields {
+		fp := fieldPath(path, field.path)
+
+		if field.err != nil {
+			w.fail(fp, "", field.err)
 			continue
 		}
 
-		// Check if field is a Pointer
-		if v.Field(i).Kind() == reflect.Struct {
-			if err := st.readStruct(v.Field(i)); err != nil {
-				return err
-			}
-			continue
+		w.readValue(v.FieldByIndex(field.index), fp, field.rules)
+	}
+}
+
+// readValue sanitizes an addressable value based on its kind
+func (w *walker) readValue(v reflect.Value, path string, tr fieldRules) {
+	// Values reached through unexported fields cannot be set, structs and pointers
+	// are still walked because embedded structs promote settable fields
+	if !v.CanSet() && v.Kind() != reflect.Struct && v.Kind() != reflect.Ptr {
+		if !tr.empty() {
+			w.unsettableField(path)
 		}
 
-		// Check if field is a Slice
-		if v.Field(i).Kind() == reflect.Slice {
-			if err := st.readSlice(v.Field(i), tagValue); err != nil {
-				return err
-			}
-			continue
+		return
+	}
+
+	// Limit nesting of deep values, self references are stopped by visit
+	if w.maxDepth > 0 && w.depth >= w.maxDepth {
+		if !w.tooDeep {
+			w.fail(path, "", ErrMaxDepth)
+			w.tooDeep = true
 		}
+		return
+	}
 
-		// Check if field is a string
-		if v.Field(i).Kind() == reflect.String {
-			if err := st.checkFields(tagValue, v, i, field); err != nil {
-				return err
-			}
+	w.depth++
+	defer func() { w.depth-- }()
+
+	// Values reached through unexported fields cannot call their methods
+	if hooks := typeHooks(v.Type()); hooks != 0 && v.CanAddr() && v.Addr().CanInterface() {
+		w.readHooked(v, path, hooks, func() { w.readKind(v, path, tr) })
+		return
+	}
+
+	w.readKind(v, path, tr)
+}
+
+// readKind sanitizes an addressable value based on its kind
+func (w *walker) readKind(v reflect.Value, path string, tr fieldRules) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		// Nil pointers are left untouched, so are the originals still shared by a copy
+		// Values shared by several pointers are sanitized once
+		if !v.IsNil() && (v.CanSet() || !w.copied) && w.visit(v) {
+			w.readValue(v.Elem(), path, tr)
 		}
+
+	case reflect.Struct:
+		w.readStruct(v, path)
+
+	case reflect.Slice, reflect.Array:
+		w.readSlice(v, path, tr)
+
+	case reflect.Map:
+		w.readMap(v, path, tr)
+
+	case reflect.Interface:
+		w.readInterface(v, path, tr)
+
+	case reflect.String:
+		w.readString(v, path, tr)
 	}
+}
 
-	return nil
+// visit records the value behind a pointer or a map and reports whether it is reached for the first time in the run
+func (w *walker) visit(v reflect.Value) bool {
+	key := visitKey{t: v.Type(), p: v.Pointer()}
+	if w.visited[key] {
+		return false
+	}
+
+	if w.visited == nil {
+		w.visited = make(map[visitKey]bool)
+	}
+	w.visited[key] = true
+
+	return true
 }
 
-// readSlice checks if a slice contains strings or structs to sanitize
-func (st *StructSanitizer) readSlice(v reflect.Value, tagValue string) error {
+// readSlice sanitizes every element of a slice or an array
+func (w *walker) readSlice(v reflect.Value, path string, tr fieldRules) {
 	for j := 0; j < v.Len(); j++ {
-		element := v.Index(j)
+		w.readValue(v.Index(j), fmt.Sprintf("%s[%d]", path, j), tr)
+	}
+}
 
-		if element.Kind() == reflect.Ptr {
-			if !element.IsNil() && element.Elem().Kind() == reflect.Struct {
-				if err := st.readStruct(element.Elem()); err != nil {
-					return err
-				}
-			}
-		} else if element.Kind() == reflect.Struct {
-			if err := st.readStruct(element); err != nil {
-				return err
-			}
-		} else if element.Kind() == reflect.String {
-			if tagValue != "" {
-				if st.verbose {
-					fmt.Printf("Slice Element Sanitization Tag: %s \n", tagValue)
-				}
-				fieldValue, err := st.sanitizeString(tagValue, element.String())
-				if err != nil {
-					return err
-				}
-				if element.CanSet() {
-					element.SetString(fieldValue)
+// readMap sanitizes map values with the field rules and string keys with the keys= rules
+func (w *walker) readMap(v reflect.Value, path string, tr fieldRules) {
+	// Maps shared by several values or holding themselves are sanitized once
+	if v.IsNil() || !w.visit(v) {
+		return
+	}
+
+	type entry struct {
+		key      reflect.Value
+		newKey   reflect.Value
+		newValue reflect.Value
+		renamed  bool
+	}
+
+	keys := v.MapKeys()
+	sortKeys(keys)
+
+	entries := make([]entry, 0, len(keys))
+	seen := make(map[string]string, len(keys))
+	collided := false
+
+	// Renamed keys are reported once no keys collide, colliding keys are all kept
+	var keyChanges []Change
+
+	for _, key := range keys {
+		elemPath := fmt.Sprintf("%s[%v]", path, key)
+		e := entry{key: key, newKey: key, newValue: w.readCopy(v.MapIndex(key), elemPath, tr)}
+
+		// Only string keys can be sanitized, other keys stay unique as they are, failed keys are kept
+		if key.Kind() == reflect.String && len(tr.keys) > 0 {
+			mark := w.changeMark()
+			sanitized, ok := w.sanitizeString(elemPath, tr.keys, tr.reject, key.String())
+			keyChanges = append(keyChanges, w.takeChanges(mark)...)
+
+			if ok {
+				if other, ok := seen[sanitized]; ok {
+					w.fail(path, "", fmt.Errorf("%w: keys %q and %q collide as %q after sanitization", ErrKeyCollision, other, key.String(), sanitized))
+					collided = true
 				}
+
+				seen[sanitized] = key.String()
+				e.newKey = reflect.ValueOf(sanitized).Convert(key.Type())
+				e.renamed = sanitized != key.String()
 			}
 		}
-	}
-	return nil
-}
 
-// checkFields checks if sanitization is possible
-func (st *StructSanitizer) checkFields(tagValue string, v reflect.Value, i int, field reflect.StructField) error {
-	if st.verbose {
-		fmt.Printf("Field Name: %s, Field Value: %s, Field Sanitization Tag: %s \n", field.Name, v.Field(i).Interface(), tagValue)
+		entries = append(entries, e)
 	}
 
-	//Sanitize strings
-	if tagValue != "" {
-		fieldValue, err := st.sanitizeString(tagValue, v.Field(i).String())
-		if err != nil {
-			return err
+	// Keys are left untouched when they collide, values are still sanitized
+	if collided {
+		for _, e := range entries {
+			v.SetMapIndex(e.key, e.newValue)
 		}
-
-		// Assign value to field
-		v.Field(i).Set(reflect.ValueOf(fieldValue))
+		return
 	}
 
-	return nil
-}
+	w.addChanges(keyChanges)
 
-// sanitizeString sanitize field based on the given struct tag(xss, domain, url, uri)
-func (st *StructSanitizer) sanitizeString(tagValue string, fieldValue string) (string, error) {
+	// Remove renamed keys before writing, so a new key never overwrites an old one
+	for _, e := range entries {
+		if e.renamed {
+			v.SetMapIndex(e.key, reflect.Value{})
+		}
+	}
 
-	// Sanitize Html
-	if tagValue == htmlField {
-		fieldValue = HTML(fieldValue)
+	for _, e := range entries {
+		v.SetMapIndex(e.newKey, e.newValue)
+	}
+}
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
+// readCopy returns the sanitized copy of a value that is not addressable, like map values or interface contents
+func (w *walker) readCopy(value reflect.Value, path string, tr fieldRules) reflect.Value {
+	if value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value
 		}
+
+		return w.readCopy(value.Elem(), path, tr)
 	}
 
-	// Sanitize xml
-	if tagValue == xmlField {
-		fieldValue = XML(fieldValue)
+	// Copy the value so it can be set
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
-		}
+	w.readValue(copied, path, tr)
+
+	return copied
+}
+
+// readInterface sanitizes the dynamic value of an interface and stores the result back
+func (w *walker) readInterface(v reflect.Value, path string, tr fieldRules) {
+	if !v.IsNil() {
+		v.Set(w.readCopy(v.Elem(), path, tr))
 	}
+}
 
-	// Escape Html
-	if tagValue == htmlEscapeField {
-		fieldValue = HtmlEscape(fieldValue)
+// readString sanitizes a string or a named string type like `type Email string`
+func (w *walker) readString(v reflect.Value, path string, tr fieldRules) {
+	if len(tr.values) == 0 {
+		return
+	}
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
-		}
+	// Failed fields keep their value
+	if value, ok := w.sanitizeString(path, tr.values, tr.reject, v.String()); ok {
+		v.SetString(value)
 	}
+}
 
-	// Sanitize scripts
-	if tagValue == scriptsField {
-		fieldValue = Scripts(fieldValue)
+// unsettableField applies the unsettable policy to a tagged field that cannot be set
+func (w *walker) unsettableField(path string) {
+	if w.unsettable == UnsettableSkip {
+		w.logSkipped(w.log, path)
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
-		}
+		w.skipped = append(w.skipped, path)
+		return
 	}
 
-	// Sanitize url
-	if tagValue == urlField {
-		value, err := URL(fieldValue, true)
+	w.fail(path, "", ErrUnsettable)
+}
+
+// sanitizeString applies every rule in order, failures are recorded and reported with ok false.
+// With reject a value the rules would change fails with ErrRejected on the first rule changing it.
+func (w *walker) sanitizeString(path string, rules []compiledRule, reject bool, fieldValue string) (string, bool) {
+	original, rejectedBy := fieldValue, ""
+
+	// Changes are only reported when the whole chain succeeds
+	mark := w.changeMark()
+
+	for _, r := range rules {
+		value, err := w.applyRule(r, fieldValue)
 		if err != nil {
-			return "", err
+			w.fail(path, r.String(), err)
+			w.dropChanges(mark)
+			return original, false
 		}
 
-		fieldValue = value
+		if reject {
+			if value != fieldValue && rejectedBy == "" {
+				rejectedBy = r.String()
+			}
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
+			fieldValue = value
+			continue
 		}
+
+		w.logRule(w.log, path, r, fieldValue, value)
+		w.reportChange(path, r.String(), fieldValue, value)
+		fieldValue = value
 	}
 
-	// Sanitize uri
-	if tagValue == uriField {
-		fieldValue = URI(fieldValue)
+	if reject && fieldValue != original {
+		w.fail(path, rejectedBy, ErrRejected)
+		return original, false
+	}
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
-		}
+	return fieldValue, true
+}
+
+// fieldPath joins a struct path and a field name, e.g. Address.ZipCode, an empty name is the struct itself
+func fieldPath(path string, name string) string {
+	if path == "" {
+		return name
 	}
 
-	// Sanitize Alpha with spaces
-	if tagValue == alphaField {
-		fieldValue = Alpha(fieldValue, true)
+	if name == "" {
+		return path
+	}
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
-		}
+	return path + "." + name
+}
+
+// sortKeys orders map keys so errors and logs are deterministic
+func sortKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+}
+
+// tag returns the struct tag name, falling back to the default one
+func (st *StructSanitizer) tag() string {
+	if st.tagName == "" {
+		return defaultTagName
 	}
 
-	// Sanitize AlphaNumeric with spaces
-	if tagValue == alphanumericField {
-		fieldValue = AlphaNumeric(fieldValue, true)
+	return st.tagName
+}
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
-		}
+// Register adds a custom rule to this sanitizer only, leaving the package-level rules untouched
+func (st *StructSanitizer) Register(name string, fn func(string) (string, error)) error {
+	if fn == nil {
+		return fmt.Errorf("sanitize rule %s has no function", name)
+	}
+
+	return st.RegisterFactory(name, noArgs(name, fn))
+}
+
+// RegisterFactory adds a custom rule accepting arguments to this sanitizer only
+func (st *StructSanitizer) RegisterFactory(name string, factory RuleFactory) error {
+	if st.registry == nil {
+		st.registry = NewRegistry()
+	}
+
+	if err := st.registry.RegisterFactory(name, factory); err != nil {
+		return err
 	}
 
-	//Sanitize XSS
-	if tagValue == xssField {
-		fieldValue = XSS(fieldValue)
+	// Plans resolved the rules of the previous registry
+	st.resetPlans()
 
-		if st.verbose {
-			fmt.Printf("Sanitized: %s\n", fieldValue)
+	return nil
+}
+
+// lookupRule finds a rule in the package-level registry first, so instance registries never replace
+// the built-in rules, and then in the instance registry
+func (st *StructSanitizer) lookupRule(name string) (RuleFactory, bool) {
+	if factory, ok := defaultRegistry.Lookup(name); ok {
+		return factory, true
+	}
+
+	if st.registry != nil {
+		return st.registry.Lookup(name)
+	}
+
+	return nil, false
+}
+
+// applyRule sanitize field based on a single compiled rule(xss, domain, url, uri)
+func (st *StructSanitizer) applyRule(r compiledRule, fieldValue string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+
+	if r.fn != nil {
+		return r.fn(fieldValue)
+	}
+
+	// Rules registered after the plan was built
+	factory, ok := st.lookupRule(r.name)
+	if !ok {
+		if st.strict {
+			return "", ErrUnknownRule
 		}
+
+		return fieldValue, nil
+	}
+
+	fn, err := factory(r.args)
+	if err != nil {
+		return "", err
 	}
 
-	return fieldValue, nil
+	return fn(fieldValue)
 }
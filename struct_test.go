@@ -1,6 +1,8 @@
 package sanitizer
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -206,3 +208,202 @@ func TestStruct(t *testing.T) {
 		})
 	}
 }
+
+func TestStructMaps(t *testing.T) {
+	type Meta string
+
+	type Item struct {
+		Name string `sanitize:"html"`
+	}
+
+	payload := &struct {
+		Metadata map[string]string            `sanitize:"xss"`
+		Labels   map[string]string            `sanitize:"keys=alphanumeric(spaces=false), values=html"`
+		Named    map[Meta]Meta                `sanitize:"keys=alpha, html"`
+		Items    map[string]Item              `sanitize:"keys=alpha"`
+		Pointers map[string]*Item             `sanitize:"xss"`
+		Lists    map[string][]string          `sanitize:"html"`
+		Nested   map[string]map[string]string `sanitize:"keys=alpha, values=html"`
+		Counts   map[int]string               `sanitize:"keys=alpha, values=html"`
+		Empty    map[string]string            `sanitize:"xss"`
+		Untagged map[string]map[string]string
+	}{
+		Metadata: map[string]string{"source": `web<script>alert(1)</script>`},
+		Labels:   map[string]string{"<b>team</b>!": `<i>core</i>`},
+		Named:    map[Meta]Meta{"type 1": `<b>named</b>`},
+		Items:    map[string]Item{"first1": {Name: `<p>first</p>`}},
+		Pointers: map[string]*Item{"second": {Name: `<p>second</p>`}, "nil": nil},
+		Lists:    map[string][]string{"list": {`<b>one</b>`, `<b>two</b>`}},
+		Nested:   map[string]map[string]string{"outer1": {"inner2": `<b>deep</b>`}},
+		Counts:   map[int]string{1: `<b>one</b>`},
+		Untagged: map[string]map[string]string{"key": {"key": `<b>kept</b>`}},
+	}
+
+	if err := Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "Metadata", got: payload.Metadata, want: map[string]string{"source": "web"}},
+		{name: "Labels", got: payload.Labels, want: map[string]string{"bteamb": "core"}},
+		{name: "Named", got: payload.Named, want: map[Meta]Meta{"type ": "named"}},
+		{name: "Items", got: payload.Items, want: map[string]Item{"first": {Name: "first"}}},
+		{name: "Pointers", got: payload.Pointers["second"].Name, want: "second"},
+		{name: "Lists", got: payload.Lists, want: map[string][]string{"list": {"one", "two"}}},
+		{name: "Nested", got: payload.Nested, want: map[string]map[string]string{"outer": {"inner": "deep"}}},
+		{name: "Counts", got: payload.Counts, want: map[int]string{1: "one"}},
+		{name: "Empty", got: payload.Empty, want: map[string]string(nil)},
+		{name: "Untagged", got: payload.Untagged, want: map[string]map[string]string{"key": {"key": `<b>kept</b>`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("%s sanitize error = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructMapKeyCollision(t *testing.T) {
+	payload := &struct {
+		Labels map[string]string `sanitize:"keys=alpha, values=html"`
+	}{
+		Labels: map[string]string{"team1": "<b>a</b>", "team2": "b", "other": "c"},
+	}
+
+	err := Struct("sanitize", payload)
+	if err == nil {
+		t.Fatalf("Struct() expected collision error")
+	}
+
+	if !strings.Contains(err.Error(), `keys "team1" and "team2" collide as "team"`) {
+		t.Errorf("Struct() error = %v", err)
+	}
+
+	// Keys are kept when they collide, values are still sanitized
+	if len(payload.Labels) != 3 || payload.Labels["team1"] != "a" || payload.Labels["other"] != "c" {
+		t.Errorf("Labels on collision = %v", payload.Labels)
+	}
+}
+
+func TestStructKinds(t *testing.T) {
+	type Email string
+
+	type Contact struct {
+		Email Email `sanitize:"html"`
+	}
+
+	name := `<b>Name</b>`
+	nickname := &name
+	first := `<i>first</i>`
+
+	payload := &struct {
+		Name      *string     `sanitize:"html"`
+		Missing   *string     `sanitize:"html"`
+		Nickname  **string    `sanitize:"html"`
+		Email     Email       `sanitize:"html"`
+		Emails    []Email     `sanitize:"html"`
+		Optional  []*string   `sanitize:"html"`
+		Codes     [2]string   `sanitize:"alpha"`
+		Matrix    [][]string  `sanitize:"html"`
+		Grid      [2][]string `sanitize:"html"`
+		Contacts  [1]Contact
+		Pointers  []*Contact
+		Addresses map[string][2]string `sanitize:"html"`
+	}{
+		Name:      &name,
+		Nickname:  &nickname,
+		Email:     `<b>john@example.com</b>`,
+		Emails:    []Email{`<i>jane@example.com</i>`},
+		Optional:  []*string{&first, nil},
+		Codes:     [2]string{"ab1", "cd2"},
+		Matrix:    [][]string{{`<b>a</b>`, `<b>b</b>`}, {`<b>c</b>`}},
+		Grid:      [2][]string{{`<b>d</b>`}, nil},
+		Contacts:  [1]Contact{{Email: `<b>contact@example.com</b>`}},
+		Pointers:  []*Contact{{Email: `<b>pointer@example.com</b>`}, nil},
+		Addresses: map[string][2]string{"home": {`<b>street</b>`, `<b>city</b>`}},
+	}
+
+	if err := Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "Name", got: *payload.Name, want: "Name"},
+		{name: "Missing", got: payload.Missing, want: (*string)(nil)},
+		{name: "Nickname", got: **payload.Nickname, want: "Name"},
+		{name: "Email", got: payload.Email, want: Email("john@example.com")},
+		{name: "Emails", got: payload.Emails, want: []Email{"jane@example.com"}},
+		{name: "Optional", got: *payload.Optional[0], want: "first"},
+		{name: "Optional nil", got: payload.Optional[1], want: (*string)(nil)},
+		{name: "Codes", got: payload.Codes, want: [2]string{"ab", "cd"}},
+		{name: "Matrix", got: payload.Matrix, want: [][]string{{"a", "b"}, {"c"}}},
+		{name: "Grid", got: payload.Grid, want: [2][]string{{"d"}, nil}},
+		{name: "Contacts", got: payload.Contacts[0].Email, want: Email("contact@example.com")},
+		{name: "Pointers", got: payload.Pointers[0].Email, want: Email("pointer@example.com")},
+		{name: "Addresses", got: payload.Addresses, want: map[string][2]string{"home": {"street", "city"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("%s sanitize error = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+
+	failing := &struct {
+		Address Address
+		Sites   [][]string `sanitize:"url"`
+	}{
+		Sites: [][]string{{"example.com"}, {"example.com", "http://\x00invalid"}},
+	}
+
+	err := Struct("sanitize", failing)
+	if err == nil || !strings.Contains(err.Error(), "field Sites[1][1]") {
+		t.Errorf("Struct() error = %v", err)
+	}
+}
+
+func TestStructSharedPointers(t *testing.T) {
+	type Note struct {
+		Text string `sanitize:"html_escape"`
+	}
+
+	text := `<b>`
+	note := &Note{Text: `<i>`}
+
+	payload := &struct {
+		Title    *string `sanitize:"html_escape"`
+		Subtitle *string `sanitize:"html_escape"`
+		First    *Note
+		Second   *Note
+		Notes    []*Note
+	}{
+		Title:    &text,
+		Subtitle: &text,
+		First:    note,
+		Second:   note,
+		Notes:    []*Note{note, note},
+	}
+
+	if err := Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	// Values shared by several pointers are escaped once
+	if text != "&lt;b&gt;" {
+		t.Errorf("shared string = %q, want %q", text, "&lt;b&gt;")
+	}
+
+	if note.Text != "&lt;i&gt;" {
+		t.Errorf("shared struct = %q, want %q", note.Text, "&lt;i&gt;")
+	}
+}
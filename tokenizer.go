@@ -0,0 +1,324 @@
+package sanitizer
+
+import "strings"
+
+// tokenType is the kind of a markup token
+type tokenType int
+
+const (
+	textToken tokenType = iota
+	startTagToken
+	endTagToken
+	selfClosingTagToken
+	commentToken // comments, doctypes and processing instructions
+	rawTextToken // content of script, style and the other elements read as plain text
+	cdataToken   // <![CDATA[...]]> sections, raw holds their content
+)
+
+// attribute is a tag attribute, the value is kept as written without its quotes
+type attribute struct {
+	name  string
+	value string
+}
+
+// token is a piece of markup, name is lower cased and raw holds the input it was read from
+type token struct {
+	typ   tokenType
+	name  string
+	attrs []attribute
+	raw   string
+	eof   bool // the tag or comment runs to the end of the input without its closing >, browsers drop it
+}
+
+// rawTextElements hold text until their closing tag, markup inside them is not parsed
+var rawTextElements = map[string]bool{
+	"script":    true,
+	"style":     true,
+	"xmp":       true,
+	"iframe":    true,
+	"noembed":   true,
+	"noframes":  true,
+	"noscript":  true,
+	"textarea":  true,
+	"title":     true,
+	"plaintext": true,
+}
+
+// foreignElements hold SVG and MathML, where style, title and the other raw text elements are parsed as markup
+var foreignElements = map[string]bool{
+	"svg":  true,
+	"math": true,
+}
+
+// tokenizer splits HTML or XML into tokens the way browsers read tags, it never fails:
+// stray < characters are text and unterminated tags run to the end of the input
+type tokenizer struct {
+	input   string
+	pos     int
+	raw     string   // element whose content is read as raw text
+	xml     bool     // XML has no raw text elements
+	foreign []string // open svg and math elements
+}
+
+// newTokenizer returns a tokenizer reading input as HTML, or as XML when xml is set
+func newTokenizer(input string, xml bool) *tokenizer {
+	return &tokenizer{input: input, xml: xml}
+}
+
+// next returns the next token, ok is false at the end of the input
+func (z *tokenizer) next() (t token, ok bool) {
+	if z.pos >= len(z.input) {
+		return token{}, false
+	}
+
+	if z.raw != "" {
+		return z.rawText(), true
+	}
+
+	start := z.pos
+	if z.input[z.pos] == '<' {
+		if t, ok := z.markup(); ok {
+			if t.typ != cdataToken {
+				t.raw = z.input[start:z.pos]
+			}
+			return t, true
+		}
+	}
+
+	// Text runs until the next < that starts markup
+	z.pos++
+	for z.pos < len(z.input) {
+		if z.input[z.pos] == '<' && z.startsMarkup(z.pos) {
+			break
+		}
+		z.pos++
+	}
+
+	return token{typ: textToken, raw: z.input[start:z.pos]}, true
+}
+
+// startsMarkup reports whether the < at i opens a tag, a comment or a declaration
+func (z *tokenizer) startsMarkup(i int) bool {
+	if i+1 >= len(z.input) {
+		return false
+	}
+
+	c := z.input[i+1]
+	if isASCIILetter(c) || c == '!' || c == '?' {
+		return true
+	}
+
+	return c == '/' && i+2 < len(z.input)
+}
+
+// markup reads the tag, comment or declaration starting at the current <
+func (z *tokenizer) markup() (token, bool) {
+	if !z.startsMarkup(z.pos) {
+		return token{}, false
+	}
+
+	switch c := z.input[z.pos+1]; {
+	case c == '!' && strings.HasPrefix(z.input[z.pos:], "<!--"):
+		// <!--> and <!---> are empty comments
+		if rest := z.input[z.pos:]; strings.HasPrefix(rest, "<!-->") {
+			z.pos += 5
+		} else if strings.HasPrefix(rest, "<!--->") {
+			z.pos += 6
+		} else {
+			z.skipUntil(z.pos+4, "-->")
+		}
+		return token{typ: commentToken}, true
+
+	case c == '!' && strings.HasPrefix(z.input[z.pos:], "<![CDATA["):
+		start := z.pos + 9
+		z.skipUntil(start, "]]>")
+		end := z.pos
+		if strings.HasSuffix(z.input[start:end], "]]>") {
+			end -= 3
+		}
+
+		return token{typ: cdataToken, raw: z.input[start:end]}, true
+
+	case c == '!' || c == '?':
+		return token{typ: commentToken, eof: !z.skipUntil(z.pos+2, ">")}, true
+
+	case c == '/':
+		if !isASCIILetter(z.input[z.pos+2]) {
+			// </> and </3 are bogus comments
+			return token{typ: commentToken, eof: !z.skipUntil(z.pos+2, ">")}, true
+		}
+
+		z.pos += 2
+		t := z.tag(endTagToken)
+		t.attrs = nil
+
+		z.closeForeign(t.name)
+
+		return t, true
+	}
+
+	z.pos++
+	t := z.tag(startTagToken)
+
+	if z.xml || t.eof {
+		return t, true
+	}
+
+	switch {
+	case t.typ == startTagToken && foreignElements[t.name]:
+		z.foreign = append(z.foreign, t.name)
+
+	// HTML ignores the / of <script/>, its content is still raw text
+	case len(z.foreign) == 0 && rawTextElements[t.name]:
+		z.raw = t.name
+	}
+
+	return t, true
+}
+
+// closeForeign closes the svg or math element an end tag belongs to with the foreign elements inside it
+func (z *tokenizer) closeForeign(name string) {
+	for i := len(z.foreign) - 1; i >= 0; i-- {
+		if z.foreign[i] == name {
+			z.foreign = z.foreign[:i]
+			return
+		}
+	}
+}
+
+// tag reads the name and the attributes of a tag up to its closing >
+func (z *tokenizer) tag(typ tokenType) token {
+	t := token{typ: typ, name: strings.ToLower(z.until(" \t\n\f\r/>"))}
+
+	for z.pos < len(z.input) {
+		z.skip(" \t\n\f\r")
+		if z.pos >= len(z.input) {
+			break
+		}
+
+		switch z.input[z.pos] {
+		case '>':
+			z.pos++
+			return t
+
+		case '/':
+			z.pos++
+			if z.pos < len(z.input) && z.input[z.pos] == '>' {
+				z.pos++
+				if t.typ == startTagToken {
+					t.typ = selfClosingTagToken
+				}
+				return t
+			}
+			continue
+		}
+
+		t.attrs = append(t.attrs, z.attribute())
+	}
+
+	t.eof = true
+
+	return t
+}
+
+// attribute reads a single attribute with its optional value
+func (z *tokenizer) attribute() attribute {
+	// A leading = is part of the name, like browsers do
+	start := z.pos
+	z.pos++
+	z.until(" \t\n\f\r/>=")
+
+	a := attribute{name: strings.ToLower(z.input[start:z.pos])}
+
+	z.skip(" \t\n\f\r")
+	if z.pos >= len(z.input) || z.input[z.pos] != '=' {
+		return a
+	}
+
+	z.pos++
+	z.skip(" \t\n\f\r")
+	if z.pos >= len(z.input) {
+		return a
+	}
+
+	switch quote := z.input[z.pos]; quote {
+	case '"', '\'':
+		z.pos++
+		a.value = z.until(string(quote))
+		if z.pos < len(z.input) {
+			z.pos++
+		}
+
+	default:
+		a.value = z.until(" \t\n\f\r>")
+	}
+
+	return a
+}
+
+// rawText reads the content of a raw text element up to its closing tag
+func (z *tokenizer) rawText() token {
+	start := z.pos
+	name := z.raw
+	z.raw = ""
+
+	if name != "plaintext" {
+		for i := z.pos; i < len(z.input); i++ {
+			if z.input[i] == '<' && closesElement(z.input[i:], name) {
+				z.pos = i
+				return token{typ: rawTextToken, name: name, raw: z.input[start:i]}
+			}
+		}
+	}
+
+	z.pos = len(z.input)
+
+	return token{typ: rawTextToken, name: name, raw: z.input[start:]}
+}
+
+// closesElement reports whether s starts with the closing tag of name, e.g. </script> or </SCRIPT >
+func closesElement(s string, name string) bool {
+	if len(s) < len(name)+2 || s[1] != '/' || !strings.EqualFold(s[2:2+len(name)], name) {
+		return false
+	}
+
+	if len(s) == len(name)+2 {
+		return true
+	}
+
+	return strings.IndexByte(" \t\n\f\r/>", s[len(name)+2]) >= 0
+}
+
+// until advances to the first byte in stop and returns what it skipped
+func (z *tokenizer) until(stop string) string {
+	start := z.pos
+	for z.pos < len(z.input) && strings.IndexByte(stop, z.input[z.pos]) < 0 {
+		z.pos++
+	}
+
+	return z.input[start:z.pos]
+}
+
+// skip advances past the bytes in chars
+func (z *tokenizer) skip(chars string) {
+	for z.pos < len(z.input) && strings.IndexByte(chars, z.input[z.pos]) >= 0 {
+		z.pos++
+	}
+}
+
+// skipUntil advances past the first end found from i, or to the end of the input when end is not found
+func (z *tokenizer) skipUntil(i int, end string) bool {
+	if n := strings.Index(z.input[i:], end); n >= 0 {
+		z.pos = i + n + len(end)
+		return true
+	}
+
+	z.pos = len(z.input)
+
+	return false
+}
+
+// isASCIILetter reports whether c is a-z or A-Z
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
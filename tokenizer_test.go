@@ -0,0 +1,149 @@
+package sanitizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tokenize(input string, xml bool) []token {
+	var tokens []token
+
+	z := newTokenizer(input, xml)
+	for {
+		t, ok := z.next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		xml   bool
+		want  []token
+	}{
+		{name: "Empty", input: "", want: nil},
+		{
+			name:  "Text and tags",
+			input: `a <b>bold</B> c`,
+			want: []token{
+				{typ: textToken, raw: "a "},
+				{typ: startTagToken, name: "b", raw: "<b>"},
+				{typ: textToken, raw: "bold"},
+				{typ: endTagToken, name: "b", raw: "</B>"},
+				{typ: textToken, raw: " c"},
+			},
+		},
+		{
+			name:  "Attributes",
+			input: `<A HREF="/x" title='it''s' data-x=1 checked/>`,
+			want: []token{{
+				typ:   selfClosingTagToken,
+				name:  "a",
+				attrs: []attribute{{name: "href", value: "/x"}, {name: "title", value: "it"}, {name: "'s'", value: ""}, {name: "data-x", value: "1"}, {name: "checked"}},
+				raw:   `<A HREF="/x" title='it''s' data-x=1 checked/>`,
+			}},
+		},
+		{
+			name:  "Attribute with > inside quotes",
+			input: `<img alt="a>b" src=x>`,
+			want: []token{{
+				typ:   startTagToken,
+				name:  "img",
+				attrs: []attribute{{name: "alt", value: "a>b"}, {name: "src", value: "x"}},
+				raw:   `<img alt="a>b" src=x>`,
+			}},
+		},
+		{
+			name:  "Stray angle brackets are text",
+			input: `3 < 4 > 2 <`,
+			want:  []token{{typ: textToken, raw: "3 < 4 > 2 <"}},
+		},
+		{
+			name:  "Comments and declarations",
+			input: `<!DOCTYPE html><!-- <b> -->x<!-->y<?xml version="1.0"?><![CDATA[<b>]]></>`,
+			want: []token{
+				{typ: commentToken, raw: "<!DOCTYPE html>"},
+				{typ: commentToken, raw: "<!-- <b> -->"},
+				{typ: textToken, raw: "x"},
+				{typ: commentToken, raw: "<!-->"},
+				{typ: textToken, raw: "y"},
+				{typ: commentToken, raw: `<?xml version="1.0"?>`},
+				{typ: cdataToken, raw: "<b>"},
+				{typ: commentToken, raw: "</>"},
+			},
+		},
+		{
+			name:  "Raw text elements",
+			input: `<script>if (a<b) { x = "</b>" }</SCRIPT >after`,
+			want: []token{
+				{typ: startTagToken, name: "script", raw: "<script>"},
+				{typ: rawTextToken, name: "script", raw: `if (a<b) { x = "</b>" }`},
+				{typ: endTagToken, name: "script", raw: "</SCRIPT >"},
+				{typ: textToken, raw: "after"},
+			},
+		},
+		{
+			name:  "XML has no raw text elements",
+			input: `<script><b>x</b></script><![CDATA[a < b]]>`,
+			xml:   true,
+			want: []token{
+				{typ: startTagToken, name: "script", raw: "<script>"},
+				{typ: startTagToken, name: "b", raw: "<b>"},
+				{typ: textToken, raw: "x"},
+				{typ: endTagToken, name: "b", raw: "</b>"},
+				{typ: endTagToken, name: "script", raw: "</script>"},
+				{typ: cdataToken, raw: "a < b"},
+			},
+		},
+		{
+			name:  "Self closing raw text element",
+			input: `<script/>x</script>`,
+			want: []token{
+				{typ: selfClosingTagToken, name: "script", raw: "<script/>"},
+				{typ: rawTextToken, name: "script", raw: "x"},
+				{typ: endTagToken, name: "script", raw: "</script>"},
+			},
+		},
+		{
+			name:  "Raw text elements are markup in foreign content",
+			input: `<svg><style><b></style></svg><style><b></style>`,
+			want: []token{
+				{typ: startTagToken, name: "svg", raw: "<svg>"},
+				{typ: startTagToken, name: "style", raw: "<style>"},
+				{typ: startTagToken, name: "b", raw: "<b>"},
+				{typ: endTagToken, name: "style", raw: "</style>"},
+				{typ: endTagToken, name: "svg", raw: "</svg>"},
+				{typ: startTagToken, name: "style", raw: "<style>"},
+				{typ: rawTextToken, name: "style", raw: "<b>"},
+				{typ: endTagToken, name: "style", raw: "</style>"},
+			},
+		},
+		{
+			name:  "Unclosed raw text element",
+			input: `<style>body {}`,
+			want: []token{
+				{typ: startTagToken, name: "style", raw: "<style>"},
+				{typ: rawTextToken, name: "style", raw: "body {}"},
+			},
+		},
+		{
+			name:  "Unterminated tag",
+			input: `x<a href="y`,
+			want: []token{
+				{typ: textToken, raw: "x"},
+				{typ: startTagToken, name: "a", attrs: []attribute{{name: "href", value: "y"}}, raw: `<a href="y`, eof: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.input, tt.xml); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
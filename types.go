@@ -1,6 +1,7 @@
 package sanitizer
 
 import (
+	"errors"
 	"fmt"
 	"html"
 	"net/url"
@@ -30,9 +31,75 @@ func AlphaNumeric(input string, spaces bool) string {
 	return alphaNumericRegex.ReplaceAllString(input, emptySpace)
 }
 
-// HTML Removes html/xml tags
+// Domain returns the lower cased host of a URL or of a bare host name, without its credentials, port and path.
+// Internationalized names like münchen.de are converted to Punycode, the host has to be a valid domain name
+// per RFC 1123 and an empty input is returned as is. Wrap it in RejectHomograph to refuse hosts mixing scripts.
+func Domain(input string, removeWww bool) (string, error) {
+	raw := strings.TrimSpace(input)
+	if raw == "" {
+		return raw, nil
+	}
+
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + strings.TrimPrefix(raw, "//")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return input, fmt.Errorf("invalid domain %v", err)
+	}
+
+	// The root label of example.com. is implied
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if removeWww {
+		host = strings.TrimPrefix(host, "www.")
+	}
+
+	// Internationalized names are returned in their Punycode form, Punycode labels have to decode
+	host, err = ToASCII(host)
+	if err != nil {
+		return input, err
+	}
+
+	if _, err := ToUnicode(host); err != nil {
+		return input, err
+	}
+
+	if err := validateDomain(host); err != nil {
+		return input, err
+	}
+
+	return host, nil
+}
+
+// validateDomain checks a lower cased host name against RFC 1123: at most 253 characters,
+// labels of 1 to 63 letters, digits and hyphens that do not start or end with a hyphen
+func validateDomain(host string) error {
+	if host == "" {
+		return errors.New("invalid domain: host is empty")
+	}
+
+	if len(host) > 253 {
+		return fmt.Errorf("invalid domain %s: longer than 253 characters", host)
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if !domainLabelRegex.MatchString(label) {
+			return fmt.Errorf("invalid domain %s: invalid label %q", host, label)
+		}
+	}
+
+	return nil
+}
+
+// HTML Removes html tags, comments and the content of script and style elements, the text is kept as written
 func HTML(input string) string {
-	return htmlRegex.ReplaceAllString(input, emptySpace)
+	return stripTags(input, false, false)
+}
+
+// HTMLText removes html tags like HTML and decodes entities, the result is plain text and is not safe to render as HTML
+func HTMLText(input string) string {
+	return stripTags(input, false, true)
 }
 
 // HtmlEscape escapes html characters
@@ -40,9 +107,35 @@ func HtmlEscape(input string) string {
 	return html.EscapeString(input)
 }
 
-// Scripts removes scripts tags from string
+// Scripts removes script, style, iframe, object, embed, svg, math and the other elements that run code, with their content.
+// Elements that are not closed are removed to the end of the input and the rest of the markup is kept as written.
 func Scripts(input string) string {
-	return scriptsRegex.ReplaceAllString(input, "")
+	if !strings.Contains(input, "<") {
+		return input
+	}
+
+	// Removing an element can join the text around it into a new tag, e.g. <scr<script></script>ipt>
+	for {
+		cleaned := removeScripts(input)
+		if cleaned == input {
+			return cleaned
+		}
+		input = cleaned
+	}
+}
+
+// Truncate shortens the string to at most length characters
+func Truncate(input string, length int) string {
+	if length < 0 {
+		return input
+	}
+
+	runes := []rune(input)
+	if len(runes) <= length {
+		return input
+	}
+
+	return string(runes[:length])
 }
 
 // URI removes unnecessary characters from URI
@@ -50,7 +143,7 @@ func URI(input string) string {
 	return uriRegex.ReplaceAllString(input, emptySpace)
 }
 
-// URL removes unnecessary characters from URL
+// URL removes unnecessary characters from URL, internationalized hosts like münchen.de are converted to Punycode
 func URL(input string, removeWww bool) (string, error) {
 	// Missing http?
 	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
@@ -67,24 +160,53 @@ func URL(input string, removeWww bool) (string, error) {
 		u.Host = wwwRegex.ReplaceAllString(u.Host, emptySpace)
 	}
 
+	// Internationalized hosts are written in their Punycode form instead of percent encoded
+	if host := u.Hostname(); !isASCII(host) {
+		ascii, err := ToASCII(host)
+		if err != nil {
+			return input, fmt.Errorf("invalid URL %v", err)
+		}
+
+		if port := u.Port(); port != "" {
+			ascii += ":" + port
+		}
+		u.Host = ascii
+	}
+
 	return u.String(), nil
 }
 
-// XML Removes xml tags
+// XML Removes xml tags, comments and processing instructions, the content of CDATA sections is kept
 func XML(input string) string {
-	return HTML(input)
+	return stripTags(input, true, false)
+}
+
+// XMLText removes xml tags like XML and decodes entities, the result is plain text and is not safe to render as markup
+func XMLText(input string) string {
+	return stripTags(input, true, true)
 }
 
-// XSS protection against xss attacks
+// XSS removes scripts, javascript:, vbscript: and data:text/html URIs, eval, string timers, fromCharCode
+// and encoded angle brackets, then the html tags. Entities, percent encoding, JS escapes and control characters
+// are decoded before matching so obfuscated payloads like jav&#x61;script: are removed too.
 func XSS(input string) string {
 	input = Scripts(input)
-	input = xssEvalRegex.ReplaceAllString(input, "")
-	input = xssJavascriptRegex.ReplaceAllString(input, "")
-	input = xssFromCharCodeRegex.ReplaceAllString(input, "")
-	input = strings.Replace(input, "&#62;", "", -1)
-	input = strings.Replace(input, "&#60;", "", -1)
-	input = strings.Replace(input, "&lt;", "", -1)
-	input = strings.Replace(input, "&gt;", "", -1) // Fixed html entity greater-than
-	input = HTML(input)
-	return input
+
+	// Removing a threat can join the text around it into a new one, e.g. javajavascript:script:
+	for {
+		findings := threats(input)
+		if len(findings) == 0 {
+			break
+		}
+
+		sortFindings(findings)
+
+		spans := make([][2]int, len(findings))
+		for i, f := range findings {
+			spans[i] = [2]int{f.Start, f.End}
+		}
+		input = removeSpans(input, spans)
+	}
+
+	return HTML(input)
 }
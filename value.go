@@ -0,0 +1,35 @@
+package sanitizer
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Value sanitizes every string in v with the given rules and returns the result,
+// it walks decoded JSON trees like map[string]any and []any. Maps, slices and
+// pointers are updated in place, strings and structs are returned as sanitized copies.
+func Value(v any, rules ...string) (any, error) {
+	return defaultSanitizer(defaultTagName).Value(v, rules...)
+}
+
+// Value sanitizes every string in v with the given rules using this sanitizer registry
+func (st *StructSanitizer) Value(v any, rules ...string) (any, error) {
+	tr, err := parseTag(strings.Join(rules, ruleSeparator))
+	if err != nil {
+		return v, err
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return v, nil
+	}
+
+	w := st.newWalker()
+
+	value := w.readCopy(rv, "", st.compileRules(tr))
+	if err := w.err(); err != nil {
+		return value.Interface(), err
+	}
+
+	return value.Interface(), nil
+}
@@ -0,0 +1,162 @@
+package sanitizer
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	var document any
+	raw := `{
+		"title": "<b>Title</b>",
+		"count": 3,
+		"active": true,
+		"missing": null,
+		"tags": ["<i>one</i>", "two<script>alert(1)</script>", 4],
+		"author": {"name": "<b>Jane</b>", "links": [{"url": "<a href=\"#\">home</a>"}]}
+	}`
+	if err := json.Unmarshal([]byte(raw), &document); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	got, err := Value(document, "xss")
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	want := map[string]any{
+		"title":   "Title",
+		"count":   float64(3),
+		"active":  true,
+		"missing": nil,
+		"tags":    []any{"one", "two", float64(4)},
+		"author": map[string]any{
+			"name":  "Jane",
+			"links": []any{map[string]any{"url": "home"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Value() = %#v, want %#v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		value   any
+		rules   []string
+		want    any
+		wantErr bool
+	}{
+		{name: "Plain string", value: "<b>bold</b> 1", rules: []string{"html", "alpha"}, want: "bold "},
+		{name: "Rules with arguments", value: "a b c", rules: []string{"alpha(spaces=false)"}, want: "abc"},
+		{name: "Map keys", value: map[string]any{"key 1": "v"}, rules: []string{"keys=alpha(spaces=false)"}, want: map[string]any{"key": "v"}},
+		{name: "Nil value", value: nil, rules: []string{"xss"}, want: nil},
+		{name: "Non string value", value: 10, rules: []string{"xss"}, want: 10},
+		{name: "Struct value", value: Address{City: "<b>City</b>"}, rules: nil, want: Address{City: "City"}},
+		{name: "Malformed rule", value: "value", rules: []string{"alpha(spaces"}, want: "value", wantErr: true},
+		{name: "Failing rule", value: []any{"http://\x00invalid"}, rules: []string{"url"}, want: []any{"http://\x00invalid"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Value(tt.value, tt.rules...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Value() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Value() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueCycles(t *testing.T) {
+	document := map[string]any{"title": "<b>"}
+	document["self"] = document
+	document["items"] = []any{document, map[string]any{"parent": document}}
+
+	if _, err := Value(document, "html_escape"); err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	// The map holding itself is escaped once
+	if document["title"] != "&lt;b&gt;" {
+		t.Errorf("Value() title = %q, want %q", document["title"], "&lt;b&gt;")
+	}
+
+	// Cycles through slices end at the maximum depth, reported once
+	list := []any{"<b>"}
+	list = append(list, list)
+	list[1] = list
+
+	var errs *Errors
+	if _, err := Value(list, "html"); !errors.As(err, &errs) || len(errs.Fields) != 1 || !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("Value() slice cycle error = %v", err)
+	}
+}
+
+func TestStructInterfaces(t *testing.T) {
+	var body any
+	if err := json.Unmarshal([]byte(`{"note": "<b>note</b>", "items": [{"name": "<i>item</i>"}]}`), &body); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	item := &Address{City: "<b>City</b>"}
+
+	payload := &struct {
+		Body     any         `sanitize:"html"`
+		Text     interface{} `sanitize:"xss"`
+		Item     any
+		Value    any            `sanitize:"html"`
+		Nil      any            `sanitize:"html"`
+		Number   any            `sanitize:"html"`
+		Values   []any          `sanitize:"html"`
+		Metadata map[string]any `sanitize:"keys=alpha, values=html"`
+	}{
+		Body:     body,
+		Text:     `text<script>alert(1)</script>`,
+		Item:     item,
+		Value:    Address{City: "<b>Value</b>"},
+		Number:   42,
+		Values:   []any{"<b>a</b>", []any{"<b>b</b>"}},
+		Metadata: map[string]any{"key1": "<b>value</b>"},
+	}
+
+	if err := Struct("sanitize", payload); err != nil {
+		t.Fatalf("Struct() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "Body", got: payload.Body, want: map[string]any{"note": "note", "items": []any{map[string]any{"name": "item"}}}},
+		{name: "Text", got: payload.Text, want: "text"},
+		{name: "Item", got: item.City, want: "City"},
+		{name: "Value", got: payload.Value, want: Address{City: "Value"}},
+		{name: "Nil", got: payload.Nil, want: nil},
+		{name: "Number", got: payload.Number, want: 42},
+		{name: "Values", got: payload.Values, want: []any{"a", []any{"b"}}},
+		{name: "Metadata", got: payload.Metadata, want: map[string]any{"key": "value"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("%s sanitize error = %#v, want %#v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+
+	failing := &struct {
+		Body any `sanitize:"url"`
+	}{
+		Body: map[string]any{"site": "http://\x00invalid"},
+	}
+
+	err := Struct("sanitize", failing)
+	if err == nil || !strings.Contains(err.Error(), "field Body[site]") {
+		t.Errorf("Struct() error = %v", err)
+	}
+}